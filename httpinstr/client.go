@@ -0,0 +1,167 @@
+package httpinstr
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Transport is an http.RoundTripper recording outbound requests into a registry,
+// tagging instruments with the request host:
+//
+// - time: Timer of requests durations, until the response headers are received.
+//
+// - status.1xx to status.5xx: Counters of responses by status class.
+//
+// - errors.dns, errors.dial, errors.tls, errors.timeout and errors.other: Counters of failed requests.
+//
+// - conns.new and conns.reused: Counters of new and reused connections.
+type Transport struct {
+	r     *reporter.Registry
+	base  http.RoundTripper
+	cache *reporter.Cache[*hostInstruments]
+}
+
+// NewTransport creates a new Transport sending requests with base, or http.DefaultTransport
+// if nil, and registering instruments into the registry, typically scoped with r.Sub("http.client").
+func NewTransport(base http.RoundTripper, r *reporter.Registry) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		r:     r,
+		base:  base,
+		cache: reporter.NewCache[*hostInstruments](r),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hi := t.instruments(req.URL.Host)
+	var failed failure
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				hi.reused.Update(1)
+			} else {
+				hi.conns.Update(1)
+			}
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			failed.set(info.Err, dnsFailure)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			failed.set(err, dialFailure)
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			failed.set(err, tlsFailure)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	hi.time.Since(start)
+	if err != nil {
+		hi.errors[classify(err, failed.get())].Update(1)
+		return resp, err
+	}
+	if class := resp.StatusCode/100 - 1; class >= 0 && class < len(hi.status) {
+		hi.status[class].Update(1)
+	}
+	return resp, nil
+}
+
+const (
+	otherFailure = iota
+	dnsFailure
+	dialFailure
+	tlsFailure
+	timeoutFailure
+)
+
+var failures = []string{"other", "dns", "dial", "tls", "timeout"}
+
+// failure records the first failing phase of a request.
+type failure struct {
+	kind int
+	m    sync.Mutex
+}
+
+func (f *failure) set(err error, kind int) {
+	if err == nil {
+		return
+	}
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.kind == otherFailure {
+		f.kind = kind
+	}
+}
+
+func (f *failure) get() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.kind
+}
+
+// classify returns the kind of failure of a request.
+func classify(err error, kind int) int {
+	var ne net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return timeoutFailure
+	}
+	if kind != otherFailure {
+		return kind
+	}
+	var de *net.DNSError
+	if errors.As(err, &de) {
+		return dnsFailure
+	}
+	var oe *net.OpError
+	if errors.As(err, &oe) && oe.Op == "dial" {
+		return dialFailure
+	}
+	return otherFailure
+}
+
+type hostInstruments struct {
+	time   *instruments.Timer
+	status [5]*instruments.Counter
+	errors [5]*instruments.Counter
+	conns  *instruments.Counter
+	reused *instruments.Counter
+}
+
+func (t *Transport) instruments(host string) *hostInstruments {
+	tags := reporter.Tags{"host": host}
+	return t.cache.Get(tags.String(), func() *hostInstruments {
+		return newHostInstruments(t.r.WithTags(tags))
+	})
+}
+
+func newHostInstruments(r *reporter.Registry) *hostInstruments {
+	hi := &hostInstruments{
+		time: reporter.Instrument(r, "time", func() *instruments.Timer {
+			return instruments.NewTimer(-1)
+		}),
+		conns:  reporter.Instrument(r, "conns.new", instruments.NewCounter),
+		reused: reporter.Instrument(r, "conns.reused", instruments.NewCounter),
+	}
+	for i := range hi.status {
+		hi.status[i] = reporter.Instrument(r, "status."+strconv.Itoa(i+1)+"xx", instruments.NewCounter)
+	}
+	for i, f := range failures {
+		hi.errors[i] = reporter.Instrument(r, "errors."+f, instruments.NewCounter)
+	}
+	return hi
+}
@@ -0,0 +1,142 @@
+package httpinstr
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func counter(r *reporter.Registry, host, name string) int64 {
+	c, ok := r.Sub("http.client").WithTags(reporter.Tags{"host": host}).Get(name).(*instruments.Counter)
+	if !ok {
+		return -1
+	}
+	return c.Snapshot()
+}
+
+func TestTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if req.URL.Path == "/missing" {
+			http.NotFound(w, req)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+	defer s.Close()
+	host := s.Listener.Addr().String()
+
+	r := reporter.NewRegistry()
+	client := &http.Client{Transport: NewTransport(nil, r.Sub("http.client"))}
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get(s.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if c := counter(r, host, "status.2xx"); c != 2 {
+		t.Errorf("expected 2 successful requests, got %d", c)
+	}
+	if c := counter(r, host, "status.4xx"); c != 1 {
+		t.Errorf("expected 1 not found request, got %d", c)
+	}
+	if c := counter(r, host, "conns.new"); c != 1 {
+		t.Errorf("expected 1 new connection, got %d", c)
+	}
+	if c := counter(r, host, "conns.reused"); c != 2 {
+		t.Errorf("expected 2 reused connections, got %d", c)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL+"/slow", nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if c := counter(r, host, "errors.timeout"); c != 1 {
+		t.Errorf("expected 1 timeout, got %d", c)
+	}
+}
+
+func TestTransportRemovedHost(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+	host := s.Listener.Addr().String()
+
+	r := reporter.NewRegistry()
+	client := &http.Client{Transport: NewTransport(nil, r.Sub("http.client"))}
+	get := func() {
+		resp, err := client.Get(s.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get()
+	r.Snapshot()
+	get()
+	if c := counter(r, host, "status.4xx"); c != 1 {
+		t.Errorf("removed host instruments should be registered again, got %d", c)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	r := reporter.NewRegistry()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := l.Addr().String()
+	l.Close()
+	client := &http.Client{Transport: NewTransport(nil, r.Sub("http.client"))}
+	if _, err := client.Get("http://" + closed); err == nil {
+		t.Fatal("expected a dial error")
+	}
+	if c := counter(r, closed, "errors.dial"); c != 1 {
+		t.Errorf("expected 1 dial error, got %d", c)
+	}
+
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	if _, err := client.Get(s.URL); err == nil {
+		t.Fatal("expected a tls error")
+	}
+	if c := counter(r, u.Host, "errors.tls"); c != 1 {
+		t.Errorf("expected 1 tls error, got %d", c)
+	}
+
+	base := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}
+		},
+	}
+	client = &http.Client{Transport: NewTransport(base, r.Sub("http.client"))}
+	if _, err := client.Get("http://example.invalid"); err == nil {
+		t.Fatal("expected a dns error")
+	}
+	if c := counter(r, "example.invalid", "errors.dns"); c != 1 {
+		t.Errorf("expected 1 dns error, got %d", c)
+	}
+}
+
+func ExampleNewTransport() {
+	registry := reporter.NewRegistry()
+	client := &http.Client{
+		Transport: NewTransport(nil, registry.Sub("http.client")),
+	}
+	client.Get("https://example.com")
+}
@@ -0,0 +1,167 @@
+// Package httpinstr provides instrumentation for net/http servers and clients.
+package httpinstr
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Middleware records the requests served by a handler into a registry,
+// tagging instruments with the request route:
+//
+// - requests: Rate of requests per second.
+//
+// - time: Timer of requests durations.
+//
+// - inflight: Gauge of requests being served.
+//
+// - size: Reservoir of responses body sizes in bytes.
+//
+// - status.1xx to status.5xx: Counters of responses by status class.
+type Middleware struct {
+	r     *reporter.Registry
+	route func(*http.Request) string
+	cache *reporter.Cache[*routeInstruments]
+}
+
+// NewMiddleware creates a new Middleware registering instruments into the registry,
+// typically scoped with r.Sub("http.server"). The route function returns the route template of a request,
+// such as "/users/{id}", and should not return raw paths which would create
+// an instrument per path. If route is nil, requests are not tagged by route.
+func NewMiddleware(r *reporter.Registry, route func(*http.Request) string) *Middleware {
+	return &Middleware{
+		r:     r,
+		route: route,
+		cache: reporter.NewCache[*routeInstruments](r),
+	}
+}
+
+// Handler returns an http.Handler recording requests served by h under the given route
+// into the registry.
+func Handler(h http.Handler, r *reporter.Registry, route string) http.Handler {
+	m := NewMiddleware(r, func(*http.Request) string {
+		return route
+	})
+	return m.Wrap(h)
+}
+
+// Wrap returns an http.Handler recording requests served by h.
+func (m *Middleware) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var route string
+		if m.route != nil {
+			route = m.route(req)
+		}
+		ri := m.instruments(route)
+		rw := &responseWriter{ResponseWriter: w}
+		start := time.Now()
+		ri.inflight.Add(1)
+		defer func() {
+			ri.inflight.Add(-1)
+			if p := recover(); p != nil {
+				ri.record(start, http.StatusInternalServerError, rw.size)
+				panic(p)
+			}
+		}()
+		h.ServeHTTP(rw, req)
+		ri.record(start, rw.status, rw.size)
+	})
+}
+
+type routeInstruments struct {
+	requests *instruments.Rate
+	time     *instruments.Timer
+	inflight *instruments.Gauge
+	size     *instruments.Reservoir
+	status   [5]*instruments.Counter
+}
+
+func (m *Middleware) instruments(route string) *routeInstruments {
+	var tags reporter.Tags
+	if route != "" {
+		tags = reporter.Tags{"route": route}
+	}
+	return m.cache.Get(tags.String(), func() *routeInstruments {
+		return newRouteInstruments(m.r.WithTags(tags))
+	})
+}
+
+func newRouteInstruments(r *reporter.Registry) *routeInstruments {
+	ri := &routeInstruments{
+		requests: reporter.Instrument(r, "requests", instruments.NewRate),
+		time: reporter.Instrument(r, "time", func() *instruments.Timer {
+			return instruments.NewTimer(-1)
+		}),
+		inflight: reporter.Instrument(r, "inflight", func() *instruments.Gauge {
+			return instruments.NewGauge(0)
+		}),
+		size: reporter.Instrument(r, "size", func() *instruments.Reservoir {
+			return instruments.NewReservoir(-1)
+		}),
+	}
+	for i := range ri.status {
+		ri.status[i] = reporter.Instrument(r, "status."+strconv.Itoa(i+1)+"xx", instruments.NewCounter)
+	}
+	return ri
+}
+
+func (ri *routeInstruments) record(start time.Time, status int, size int64) {
+	ri.time.Since(start)
+	ri.requests.Update(1)
+	ri.size.Update(size)
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if class := status/100 - 1; class >= 0 && class < len(ri.status) {
+		ri.status[class].Update(1)
+	}
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying ResponseWriter does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("httpinstr: hijacking not supported")
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,138 @@
+package httpinstr
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func TestHandler(t *testing.T) {
+	r := reporter.NewRegistry()
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/users/0" {
+			http.NotFound(w, req)
+			return
+		}
+		io.WriteString(w, "hello")
+	}), r.Sub("http.server"), "/users/{id}")
+	s := httptest.NewServer(h)
+	defer s.Close()
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0"} {
+		resp, err := http.Get(s.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	routed := r.Sub("http.server").WithTags(reporter.Tags{"route": "/users/{id}"})
+	if c := routed.Get("status.2xx").(*instruments.Counter).Snapshot(); c != 2 {
+		t.Errorf("expected 2 successful requests, got %d", c)
+	}
+	if c := routed.Get("status.4xx").(*instruments.Counter).Snapshot(); c != 1 {
+		t.Errorf("expected 1 not found request, got %d", c)
+	}
+	if s := routed.Get("time").(*instruments.Timer).Snapshot(); len(s) != 3 {
+		t.Errorf("expected 3 timed requests, got %d", len(s))
+	}
+	if s := routed.Get("size").(*instruments.Reservoir).Snapshot(); len(s) != 3 || s[0] != 5 {
+		t.Errorf("unexpected response sizes %v", s)
+	}
+	if g := routed.Get("inflight").(*instruments.Gauge).Snapshot(); g != 0 {
+		t.Errorf("expected no inflight requests, got %d", g)
+	}
+}
+
+func TestHandlerCollision(t *testing.T) {
+	r := reporter.NewRegistry()
+	routed := r.Sub("http.server").WithTags(reporter.Tags{"route": "/"})
+	c := instruments.NewCounter()
+	routed.Register("time", c)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), r.Sub("http.server"), "/")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the request to be served, got %d", w.Code)
+	}
+	if routed.Get("time") != c {
+		t.Error("colliding instrument should not be replaced")
+	}
+	if rejected := r.Get(reporter.RejectedName).(*instruments.Counter).Snapshot(); rejected != 1 {
+		t.Errorf("expected 1 rejected registration, got %d", rejected)
+	}
+}
+
+func TestHandlerExpiredRoute(t *testing.T) {
+	r := reporter.NewRegistry()
+	r.SetTTL(time.Millisecond)
+	defer r.SetTTL(0)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), r.Sub("http.server"), "/")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	for deadline := time.Now().Add(time.Second); r.Size() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected idle route instruments to expire, got %d", r.Size())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	r.SetTTL(0)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	routed := r.Sub("http.server").WithTags(reporter.Tags{"route": "/"})
+	if c, ok := routed.Get("status.2xx").(*instruments.Counter); !ok || c.Snapshot() != 1 {
+		t.Error("expired route instruments should be registered again")
+	}
+}
+
+func TestMiddlewareRoute(t *testing.T) {
+	r := reporter.NewRegistry()
+	m := NewMiddleware(r.Sub("api"), func(req *http.Request) string {
+		return strings.SplitN(req.URL.Path, "/", 3)[1]
+	})
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	for _, path := range []string{"/a/1", "/a/2", "/b/1"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", path, nil))
+	}
+	a := r.Sub("api").WithTags(reporter.Tags{"route": "a"})
+	if c := a.Get("status.2xx").(*instruments.Counter).Snapshot(); c != 2 {
+		t.Errorf("expected 2 requests for route a, got %d", c)
+	}
+	if r.Filter(reporter.IncludeTags(reporter.Tags{"route": "b"})).Size() == 0 {
+		t.Error("route b not instrumented")
+	}
+}
+
+func TestHandlerPanic(t *testing.T) {
+	r := reporter.NewRegistry()
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), r.Sub("http.server"), "/")
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("unexpected panic %v", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	routed := r.Sub("http.server").WithTags(reporter.Tags{"route": "/"})
+	if c := routed.Get("status.5xx").(*instruments.Counter).Snapshot(); c != 1 {
+		t.Errorf("expected 1 failed request, got %d", c)
+	}
+}
+
+func ExampleHandler() {
+	registry := reporter.NewRegistry()
+	http.Handle("/users/", Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, "hello")
+	}), registry.Sub("http.server"), "/users/{id}"))
+}
@@ -69,14 +69,36 @@ type Sample interface {
 	Snapshot() []int64
 }
 
+// DiscretePeeker represents a single value instrument which can be read without being reset.
+type DiscretePeeker interface {
+	Peek() int64
+}
+
+// SamplePeeker represents a sample instrument which can be read without being reset.
+type SamplePeeker interface {
+	Peek() []int64
+}
+
 // Scale returns a conversion factor from one unit to another.
 func Scale(o, d time.Duration) float64 {
 	return float64(o) / float64(d)
 }
 
+// updateCount counts the updates of an instrument.
+type updateCount uint64
+
+func (u *updateCount) add() {
+	atomic.AddUint64((*uint64)(u), 1)
+}
+
+func (u *updateCount) load() uint64 {
+	return atomic.LoadUint64((*uint64)(u))
+}
+
 // Counter holds a counter that can be incremented or decremented.
 type Counter struct {
-	count int64
+	count   int64
+	updates updateCount
 }
 
 // NewCounter creates a new counter instrument.
@@ -87,6 +109,12 @@ func NewCounter() *Counter {
 // Update adds v to the counter.
 func (c *Counter) Update(v int64) {
 	atomic.AddInt64(&c.count, v)
+	c.updates.add()
+}
+
+// Updates returns the number of times the counter has been updated.
+func (c *Counter) Updates() uint64 {
+	return c.updates.load()
 }
 
 // Snapshot returns the current value and reset the counter.
@@ -94,6 +122,11 @@ func (c *Counter) Snapshot() int64 {
 	return atomic.SwapInt64(&c.count, 0)
 }
 
+// Peek returns the current value without resetting the counter.
+func (c *Counter) Peek() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
 // Rate tracks the rate of values per second.
 type Rate struct {
 	time  int64
@@ -116,11 +149,21 @@ func NewRateScale(d time.Duration) *Rate {
 	}
 }
 
+// Unit returns the unit of time rates are expressed per.
+func (r *Rate) Unit() time.Duration {
+	return r.unit
+}
+
 // Update updates rate value.
 func (r *Rate) Update(v int64) {
 	r.count.Update(v)
 }
 
+// Updates returns the number of times the rate has been updated.
+func (r *Rate) Updates() uint64 {
+	return r.count.Updates()
+}
+
 // Snapshot returns the number of values per second since the last snapshot,
 // and reset the count to zero.
 func (r *Rate) Snapshot() int64 {
@@ -133,6 +176,18 @@ func (r *Rate) Snapshot() int64 {
 	return Ceil(s * Scale(r.unit, time.Second))
 }
 
+// Peek returns the number of values per second since the last snapshot,
+// without resetting the count.
+func (r *Rate) Peek() int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	now := time.Now().UnixNano()
+	t := atomic.LoadInt64(&r.time)
+	c := r.count.Peek()
+	s := float64(c) / rateScale / float64(now-t)
+	return Ceil(s * Scale(r.unit, time.Second))
+}
+
 // Derive tracks the rate of deltas per seconds.
 type Derive struct {
 	rate  *Rate
@@ -155,23 +210,40 @@ func NewDeriveScale(v int64, d time.Duration) *Derive {
 	}
 }
 
+// Unit returns the unit of time rates are expressed per.
+func (d *Derive) Unit() time.Duration {
+	return d.rate.Unit()
+}
+
 // Update update rate value based on the stored previous value.
 func (d *Derive) Update(v int64) {
 	p := atomic.SwapInt64(&d.value, v)
 	d.rate.Update(v - p)
 }
 
+// Updates returns the number of times the derive has been updated.
+func (d *Derive) Updates() uint64 {
+	return d.rate.Updates()
+}
+
 // Snapshot returns the number of values per seconds since the last snapshot,
 // and reset the count to zero.
 func (d *Derive) Snapshot() int64 {
 	return d.rate.Snapshot()
 }
 
+// Peek returns the number of values per seconds since the last snapshot,
+// without resetting the count.
+func (d *Derive) Peek() int64 {
+	return d.rate.Peek()
+}
+
 // Reservoir tracks a sample of values.
 type Reservoir struct {
-	size   int64
-	values []int64
-	m      sync.Mutex
+	size    int64
+	updates updateCount
+	values  []int64
+	m       sync.Mutex
 }
 
 const defaultReservoirSize = 1028
@@ -192,6 +264,7 @@ func NewReservoir(size int64) *Reservoir {
 func (r *Reservoir) Update(v int64) {
 	r.m.Lock()
 	defer r.m.Unlock()
+	r.updates.add()
 	s := atomic.AddInt64(&r.size, 1)
 	if int(s) <= len(r.values) {
 		// Not full
@@ -205,6 +278,11 @@ func (r *Reservoir) Update(v int64) {
 	}
 }
 
+// Updates returns the number of times the reservoir has been updated.
+func (r *Reservoir) Updates() uint64 {
+	return r.updates.load()
+}
+
 // Snapshot returns sample as a sorted array.
 func (r *Reservoir) Snapshot() []int64 {
 	r.m.Lock()
@@ -217,9 +295,21 @@ func (r *Reservoir) Snapshot() []int64 {
 	return v
 }
 
+// Peek returns sample as a sorted array without resetting the reservoir.
+func (r *Reservoir) Peek() []int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	s := atomic.LoadInt64(&r.size)
+	v := make([]int64, min(int(s), len(r.values)))
+	copy(v, r.values)
+	sorted(v)
+	return v
+}
+
 // Gauge tracks a value.
 type Gauge struct {
-	value int64
+	value   int64
+	updates updateCount
 }
 
 // NewGauge creates a new Gauge with the given value.
@@ -232,6 +322,18 @@ func NewGauge(v int64) *Gauge {
 // Update updates the current stored value.
 func (g *Gauge) Update(v int64) {
 	atomic.StoreInt64(&g.value, v)
+	g.updates.add()
+}
+
+// Add adds v to the current stored value.
+func (g *Gauge) Add(v int64) {
+	atomic.AddInt64(&g.value, v)
+	g.updates.add()
+}
+
+// Updates returns the number of times the gauge has been updated.
+func (g *Gauge) Updates() uint64 {
+	return g.updates.load()
 }
 
 // Snapshot returns the current value.
@@ -239,6 +341,11 @@ func (g *Gauge) Snapshot() int64 {
 	return atomic.LoadInt64(&g.value)
 }
 
+// Peek returns the current value.
+func (g *Gauge) Peek() int64 {
+	return atomic.LoadInt64(&g.value)
+}
+
 // Timer tracks durations.
 type Timer struct {
 	r *Reservoir
@@ -251,17 +358,32 @@ func NewTimer(size int64) *Timer {
 	}
 }
 
+// Unit returns the unit of time durations are expressed in.
+func (t *Timer) Unit() time.Duration {
+	return time.Millisecond
+}
+
 // Update adds duration to the sample in ms.
 func (t *Timer) Update(d time.Duration) {
 	v := Floor(d.Seconds() * 1000)
 	t.r.Update(v)
 }
 
+// Updates returns the number of times the timer has been updated.
+func (t *Timer) Updates() uint64 {
+	return t.r.Updates()
+}
+
 // Snapshot returns durations sample as a sorted array.
 func (t *Timer) Snapshot() []int64 {
 	return t.r.Snapshot()
 }
 
+// Peek returns durations sample as a sorted array without resetting the timer.
+func (t *Timer) Peek() []int64 {
+	return t.r.Peek()
+}
+
 // Since records duration since the given start time.
 func (t *Timer) Since(start time.Time) {
 	t.Update(time.Since(start))
@@ -83,6 +83,15 @@ func TestRate(t *testing.T) {
 	}
 }
 
+func TestRateUnit(t *testing.T) {
+	if u := NewRate().Unit(); u != time.Second {
+		t.Errorf("wants %v got %v", time.Second, u)
+	}
+	if u := NewDeriveScale(0, time.Minute).Unit(); u != time.Minute {
+		t.Errorf("wants %v got %v", time.Minute, u)
+	}
+}
+
 func ExampleRate() {
 	rate := NewRate()
 	rate.Update(20)
@@ -138,6 +147,10 @@ func TestGauge(t *testing.T) {
 	if s != 2 {
 		t.Error("gauge didn't store new value")
 	}
+	g.Add(-3)
+	if s := g.Snapshot(); s != -1 {
+		t.Error("gauge didn't add value")
+	}
 }
 
 func ExampleGauge() {
@@ -191,6 +204,56 @@ func ExampleTimer_Time() {
 	fmt.Println(Quantile(s, 0.99))
 }
 
+func TestUpdates(t *testing.T) {
+	c := NewCounter()
+	if c.Updates() != 0 {
+		t.Error("counter should not be updated")
+	}
+	c.Update(1)
+	c.Snapshot()
+	if c.Updates() != 1 || c.Updates() != 1 {
+		t.Error("counter updates should be tracked and not reset")
+	}
+	tm := NewTimer(-1)
+	tm.Update(time.Second)
+	if tm.Updates() != 1 {
+		t.Error("timer update not tracked")
+	}
+	d := NewDerive(0)
+	d.Update(1)
+	if d.Updates() != 1 {
+		t.Error("derive update not tracked")
+	}
+	g := NewGauge(0)
+	g.Update(1)
+	g.Add(1)
+	if g.Updates() != 2 {
+		t.Error("gauge update not tracked")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	c := NewCounter()
+	c.Update(2)
+	if c.Peek() != 2 || c.Peek() != 2 || c.Snapshot() != 2 {
+		t.Error("counter peek should not reset the counter")
+	}
+	r := NewRate()
+	r.Update(10)
+	if r.Peek() <= 0 || r.Snapshot() <= 0 {
+		t.Error("rate peek should not reset the rate")
+	}
+	tm := NewTimer(-1)
+	tm.Update(2 * time.Millisecond)
+	tm.Update(time.Millisecond)
+	if p := tm.Peek(); !reflect.DeepEqual(p, []int64{1, 2}) {
+		t.Errorf("expected sorted sample, got %v", p)
+	}
+	if s := tm.Snapshot(); len(s) != 2 {
+		t.Error("timer peek should not reset the timer")
+	}
+}
+
 func BenchmarkCounter(b *testing.B) {
 	c := NewCounter()
 	b.ResetTimer()
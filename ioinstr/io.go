@@ -0,0 +1,83 @@
+// Package ioinstr provides wrappers of io and net types recording the bytes transferred.
+//
+// Bytes are recorded into any instrument with an Update(int64) method, such as
+// a Rate or a Counter, which are updated with the number of bytes of each call.
+// Derive instruments are updated with the total number of bytes transferred
+// instead, and thus must not be shared between wrappers.
+package ioinstr
+
+import (
+	"io"
+	"sync"
+
+	"github.com/heroku/instruments"
+)
+
+// Updater is an instrument recording values.
+type Updater interface {
+	Update(v int64)
+}
+
+// counter records bytes into an instrument.
+type counter struct {
+	u     Updater
+	m     sync.Mutex
+	total int64
+}
+
+func (c *counter) add(n int64) {
+	if n <= 0 || c.u == nil {
+		return
+	}
+	if d, ok := c.u.(*instruments.Derive); ok {
+		// Totals must be recorded in order for the derive to stay positive.
+		c.m.Lock()
+		defer c.m.Unlock()
+		c.total += n
+		d.Update(c.total)
+		return
+	}
+	c.u.Update(n)
+}
+
+// Reader records the bytes read from an io.Reader.
+type Reader struct {
+	r io.Reader
+	c counter
+}
+
+// NewReader returns a Reader recording the bytes read from r into u.
+func NewReader(r io.Reader, u Updater) *Reader {
+	return &Reader{
+		r: r,
+		c: counter{u: u},
+	}
+}
+
+// Read implements io.Reader.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.c.add(int64(n))
+	return n, err
+}
+
+// Writer records the bytes written to an io.Writer.
+type Writer struct {
+	w io.Writer
+	c counter
+}
+
+// NewWriter returns a Writer recording the bytes written to w into u.
+func NewWriter(w io.Writer, u Updater) *Writer {
+	return &Writer{
+		w: w,
+		c: counter{u: u},
+	}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.c.add(int64(n))
+	return n, err
+}
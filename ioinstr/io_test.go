@@ -0,0 +1,67 @@
+package ioinstr
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+func TestReader(t *testing.T) {
+	c := instruments.NewCounter()
+	r := NewReader(strings.NewReader("hello world"), c)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.Snapshot(); s != 11 {
+		t.Errorf("expected 11 bytes read, got %d", s)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	d := instruments.NewDerive(0)
+	w := NewWriter(&buf, d)
+	io.WriteString(w, "hello")
+	io.WriteString(w, " world")
+	if total := d.Snapshot(); total <= 0 {
+		t.Errorf("expected a positive rate, got %d", total)
+	}
+	c := instruments.NewCounter()
+	w = NewWriter(&buf, c)
+	io.WriteString(w, "hello")
+	if s := c.Snapshot(); s != 5 {
+		t.Errorf("expected 5 bytes written, got %d", s)
+	}
+}
+
+func TestWriterConcurrent(t *testing.T) {
+	d := instruments.NewDerive(0)
+	w := NewWriter(io.Discard, d)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				io.WriteString(w, "a")
+			}
+		}()
+	}
+	wg.Wait()
+	if w.c.total != 800 || d.Updates() != 800 {
+		t.Errorf("expected 800 bytes in 800 updates, got %d in %d", w.c.total, d.Updates())
+	}
+	if s := d.Snapshot(); s <= 0 {
+		t.Errorf("expected a positive rate, got %d", s)
+	}
+}
+
+func ExampleNewReader() {
+	rate := instruments.NewRate()
+	r := NewReader(strings.NewReader("hello world"), rate)
+	io.Copy(io.Discard, r)
+}
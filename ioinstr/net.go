@@ -0,0 +1,190 @@
+package ioinstr
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Conn records the bytes transferred over a net.Conn and its lifetime.
+type Conn struct {
+	net.Conn
+	read     counter
+	written  counter
+	lifetime *instruments.Timer
+	start    time.Time
+	closed   func()
+	once     sync.Once
+}
+
+// NewConn returns a Conn recording the bytes read from and written to c into
+// read and written, and its lifetime into the lifetime timer once closed.
+// Any of the instruments may be nil. The returned connection also implements
+// io.ReaderFrom, io.WriterTo and CloseWrite when c does, such as a *net.TCPConn.
+func NewConn(c net.Conn, read, written Updater, lifetime *instruments.Timer) net.Conn {
+	return wrapConn(c, read, written, lifetime, nil)
+}
+
+func wrapConn(c net.Conn, read, written Updater, lifetime *instruments.Timer, closed func()) net.Conn {
+	cn := &Conn{
+		Conn:     c,
+		read:     counter{u: read},
+		written:  counter{u: written},
+		lifetime: lifetime,
+		start:    time.Now(),
+		closed:   closed,
+	}
+	rf, _ := c.(io.ReaderFrom)
+	wt, _ := c.(io.WriterTo)
+	cw, _ := c.(closeWriter)
+	switch {
+	case rf != nil && wt != nil && cw != nil:
+		return struct {
+			*Conn
+			readerFrom
+			writerTo
+			closeWriter
+		}{cn, readerFrom{cn, rf}, writerTo{cn, wt}, cw}
+	case rf != nil && wt != nil:
+		return struct {
+			*Conn
+			readerFrom
+			writerTo
+		}{cn, readerFrom{cn, rf}, writerTo{cn, wt}}
+	case rf != nil && cw != nil:
+		return struct {
+			*Conn
+			readerFrom
+			closeWriter
+		}{cn, readerFrom{cn, rf}, cw}
+	case wt != nil && cw != nil:
+		return struct {
+			*Conn
+			writerTo
+			closeWriter
+		}{cn, writerTo{cn, wt}, cw}
+	case rf != nil:
+		return struct {
+			*Conn
+			readerFrom
+		}{cn, readerFrom{cn, rf}}
+	case wt != nil:
+		return struct {
+			*Conn
+			writerTo
+		}{cn, writerTo{cn, wt}}
+	case cw != nil:
+		return struct {
+			*Conn
+			closeWriter
+		}{cn, cw}
+	}
+	return cn
+}
+
+// Read implements net.Conn.
+func (c *Conn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.add(int64(n))
+	return n, err
+}
+
+// Write implements net.Conn.
+func (c *Conn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.add(int64(n))
+	return n, err
+}
+
+// Close implements net.Conn, recording the connection lifetime on the first call.
+func (c *Conn) Close() error {
+	c.once.Do(func() {
+		if c.lifetime != nil {
+			c.lifetime.Since(c.start)
+		}
+		if c.closed != nil {
+			c.closed()
+		}
+	})
+	return c.Conn.Close()
+}
+
+// closeWriter is implemented by connections which can be half closed, such as a *net.TCPConn.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// readerFrom forwards io.ReaderFrom, recording the bytes written to the connection.
+type readerFrom struct {
+	c  *Conn
+	rf io.ReaderFrom
+}
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	n, err := r.rf.ReadFrom(src)
+	r.c.written.add(n)
+	return n, err
+}
+
+// writerTo forwards io.WriterTo, recording the bytes read from the connection.
+type writerTo struct {
+	c  *Conn
+	wt io.WriterTo
+}
+
+func (w writerTo) WriteTo(dst io.Writer) (int64, error) {
+	n, err := w.wt.WriteTo(dst)
+	w.c.read.add(n)
+	return n, err
+}
+
+// Listener records the connections accepted by a net.Listener into a registry:
+//
+// - accepted: Counter of accepted connections.
+//
+// - active: Gauge of open accepted connections.
+//
+// - read and written: Rates of bytes transferred per second over accepted connections.
+//
+// - lifetime: Timer of accepted connections lifetimes.
+type Listener struct {
+	net.Listener
+	accepted *instruments.Counter
+	active   *instruments.Gauge
+	read     *instruments.Rate
+	written  *instruments.Rate
+	lifetime *instruments.Timer
+}
+
+// NewListener returns a Listener recording the connections accepted by l into the registry.
+func NewListener(l net.Listener, r *reporter.Registry) *Listener {
+	return &Listener{
+		Listener: l,
+		accepted: reporter.Instrument(r, "accepted", instruments.NewCounter),
+		active: reporter.Instrument(r, "active", func() *instruments.Gauge {
+			return instruments.NewGauge(0)
+		}),
+		read:    reporter.Instrument(r, "read", instruments.NewRate),
+		written: reporter.Instrument(r, "written", instruments.NewRate),
+		lifetime: reporter.Instrument(r, "lifetime", func() *instruments.Timer {
+			return instruments.NewTimer(-1)
+		}),
+	}
+}
+
+// Accept implements net.Listener, returning connections wrapped like NewConn does.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.accepted.Update(1)
+	l.active.Add(1)
+	return wrapConn(c, l.read, l.written, l.lifetime, func() {
+		l.active.Add(-1)
+	}), nil
+}
@@ -0,0 +1,141 @@
+package ioinstr
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func TestConn(t *testing.T) {
+	a, b := net.Pipe()
+	read := instruments.NewCounter()
+	written := instruments.NewCounter()
+	lifetime := instruments.NewTimer(-1)
+	c := NewConn(a, read, written, lifetime)
+	go func() {
+		io.WriteString(b, "ping")
+		buf := make([]byte, 4)
+		io.ReadFull(b, buf)
+		b.Close()
+	}()
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(c, "pong")
+	c.Close()
+	c.Close()
+	if s := read.Snapshot(); s != 4 {
+		t.Errorf("expected 4 bytes read, got %d", s)
+	}
+	if s := written.Snapshot(); s != 4 {
+		t.Errorf("expected 4 bytes written, got %d", s)
+	}
+	if s := lifetime.Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 connection lifetime, got %d", len(s))
+	}
+}
+
+func TestConnInterfaces(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	c := NewConn(a, nil, nil, nil)
+	defer c.Close()
+	if _, ok := c.(io.ReaderFrom); ok {
+		t.Error("pipe connection should not implement io.ReaderFrom")
+	}
+	if _, ok := c.(closeWriter); ok {
+		t.Error("pipe connection should not implement CloseWrite")
+	}
+}
+
+func TestConnReadFrom(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	received := make(chan int64)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		n, _ := io.Copy(io.Discard, c)
+		c.Close()
+		received <- n
+	}()
+
+	tc, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := instruments.NewCounter()
+	c := NewConn(tc, nil, written, nil)
+	defer c.Close()
+	if _, ok := c.(io.ReaderFrom); !ok {
+		t.Fatal("tcp connection should implement io.ReaderFrom")
+	}
+	if n, err := c.(io.ReaderFrom).ReadFrom(strings.NewReader("hello")); err != nil || n != 5 {
+		t.Fatalf("unexpected copy of %d bytes: %v", n, err)
+	}
+	cw, ok := c.(closeWriter)
+	if !ok {
+		t.Fatal("tcp connection should implement CloseWrite")
+	}
+	if err := cw.CloseWrite(); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-received; n != 5 {
+		t.Errorf("expected 5 bytes received, got %d", n)
+	}
+	if s := written.Snapshot(); s != 5 {
+		t.Errorf("expected 5 bytes written, got %d", s)
+	}
+}
+
+func TestListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := reporter.NewRegistry()
+	il := NewListener(l, r)
+	defer il.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		io.WriteString(c, "hello")
+		c.Close()
+	}()
+	c, err := il.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Get("active").(*instruments.Gauge).Snapshot(); s != 1 {
+		t.Errorf("expected 1 active connection, got %d", s)
+	}
+	io.Copy(io.Discard, c)
+	c.Close()
+
+	if s := r.Get("accepted").(*instruments.Counter).Snapshot(); s != 1 {
+		t.Errorf("expected 1 accepted connection, got %d", s)
+	}
+	if s := r.Get("active").(*instruments.Gauge).Snapshot(); s != 0 {
+		t.Errorf("expected no active connection, got %d", s)
+	}
+	if s := r.Get("lifetime").(*instruments.Timer).Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 connection lifetime, got %d", len(s))
+	}
+	if s := r.Get("read").(*instruments.Rate).Snapshot(); s <= 0 {
+		t.Errorf("expected a positive read rate, got %d", s)
+	}
+}
@@ -0,0 +1,98 @@
+package queueinstr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Pool collects the utilization of a pool of workers into a registry:
+//
+// - busy: Gauge of busy workers.
+//
+// - idle: Gauge of idle workers.
+//
+// - utilization: Gauge of the percentage of worker time spent busy since the last update.
+type Pool struct {
+	size        int
+	active      int
+	busyTime    time.Duration
+	last        time.Time
+	updated     time.Time
+	busy        *instruments.Gauge
+	idle        *instruments.Gauge
+	utilization *instruments.Gauge
+	m           sync.Mutex
+}
+
+// NewPool creates a new Pool of size workers collecting into the registry.
+func NewPool(size int, r *reporter.Registry) *Pool {
+	now := time.Now()
+	return &Pool{
+		size:        size,
+		last:        now,
+		updated:     now,
+		busy:        reporter.Instrument(r, "busy", newGauge),
+		idle:        reporter.Instrument(r, "idle", newGauge),
+		utilization: reporter.Instrument(r, "utilization", newGauge),
+	}
+}
+
+// Busy marks a worker as busy, and returns a function marking it idle again.
+func (p *Pool) Busy() func() {
+	p.add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.add(-1)
+		})
+	}
+}
+
+// Do marks a worker as busy while calling f.
+func (p *Pool) Do(f func()) {
+	defer p.Busy()()
+	f()
+}
+
+// add changes the number of busy workers, accumulating the busy time so far.
+func (p *Pool) add(n int) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.accumulate(time.Now())
+	p.active += n
+}
+
+func (p *Pool) accumulate(now time.Time) {
+	p.busyTime += time.Duration(p.active) * now.Sub(p.last)
+	p.last = now
+}
+
+// Update updates the busy and idle workers and the pool utilization.
+func (p *Pool) Update() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	now := time.Now()
+	p.accumulate(now)
+	if total := time.Duration(p.size) * now.Sub(p.updated); total > 0 {
+		p.utilization.Update(int64(100 * p.busyTime / total))
+	}
+	p.busy.Update(int64(p.active))
+	p.idle.Update(int64(p.size - p.active))
+	p.busyTime = 0
+	p.updated = now
+}
+
+// Snapshot returns the current pool utilization.
+func (p *Pool) Snapshot() int64 {
+	return p.utilization.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (p *Pool) Peek() int64 {
+	return p.utilization.Peek()
+}
@@ -0,0 +1,36 @@
+package queueinstr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func TestPool(t *testing.T) {
+	r := reporter.NewRegistry()
+	p := NewPool(2, r)
+	done := p.Busy()
+	time.Sleep(20 * time.Millisecond)
+	p.Update()
+	if s := p.Snapshot(); s < 40 || s > 50 {
+		t.Errorf("expected a utilization of about 50%%, got %d", s)
+	}
+	if s := r.Get("busy").(*instruments.Gauge).Snapshot(); s != 1 {
+		t.Errorf("expected 1 busy worker, got %d", s)
+	}
+	if s := r.Get("idle").(*instruments.Gauge).Snapshot(); s != 1 {
+		t.Errorf("expected 1 idle worker, got %d", s)
+	}
+	done()
+	done()
+	time.Sleep(10 * time.Millisecond)
+	p.Update()
+	if s := p.Snapshot(); s != 0 {
+		t.Errorf("expected no utilization, got %d", s)
+	}
+	if s := r.Get("busy").(*instruments.Gauge).Snapshot(); s != 0 {
+		t.Errorf("expected no busy worker, got %d", s)
+	}
+}
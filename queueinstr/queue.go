@@ -0,0 +1,87 @@
+// Package queueinstr provides instrumentations of queues, channels and worker pools.
+package queueinstr
+
+import (
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Queue is a queue with a length and a capacity.
+type Queue interface {
+	Len() int
+	Cap() int
+}
+
+type channel[T any] struct {
+	c <-chan T
+}
+
+func (c channel[T]) Len() int { return len(c.c) }
+func (c channel[T]) Cap() int { return cap(c.c) }
+
+// Channel returns a Queue reporting the length and capacity of a channel.
+func Channel[T any](c <-chan T) Queue {
+	return channel[T]{c: c}
+}
+
+// Length collects the length and capacity of a queue into a registry:
+//
+// - len: Gauge of queued items.
+//
+// - cap: Gauge of the queue capacity.
+type Length struct {
+	q   Queue
+	len *instruments.Gauge
+	cap *instruments.Gauge
+}
+
+// NewLength creates a new Length collecting the length and capacity of q into the registry.
+func NewLength(q Queue, r *reporter.Registry) *Length {
+	return &Length{
+		q:   q,
+		len: reporter.Instrument(r, "len", newGauge),
+		cap: reporter.Instrument(r, "cap", newGauge),
+	}
+}
+
+// Update samples the length and capacity of the queue.
+func (l *Length) Update() {
+	l.len.Update(int64(l.q.Len()))
+	l.cap.Update(int64(l.q.Cap()))
+}
+
+// Snapshot returns the current length of the queue.
+func (l *Length) Snapshot() int64 {
+	return l.len.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (l *Length) Peek() int64 {
+	return l.len.Peek()
+}
+
+// Timed is a queued value holding its enqueue time.
+type Timed[T any] struct {
+	Value    T
+	Enqueued time.Time
+}
+
+// Enqueue returns v timestamped with the current time.
+func Enqueue[T any](v T) Timed[T] {
+	return Timed[T]{
+		Value:    v,
+		Enqueued: time.Now(),
+	}
+}
+
+// Dequeue records the time spent in queue into the timer and returns the value.
+func (t Timed[T]) Dequeue(timer *instruments.Timer) T {
+	timer.Since(t.Enqueued)
+	return t.Value
+}
+
+func newGauge() *instruments.Gauge {
+	return instruments.NewGauge(0)
+}
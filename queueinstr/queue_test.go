@@ -0,0 +1,48 @@
+package queueinstr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func TestLength(t *testing.T) {
+	r := reporter.NewRegistry()
+	c := make(chan int, 10)
+	c <- 1
+	c <- 2
+	l := NewLength(Channel(c), r)
+	l.Update()
+	if s := l.Snapshot(); s != 2 {
+		t.Errorf("expected 2 queued items, got %d", s)
+	}
+	if s := r.Get("cap").(*instruments.Gauge).Snapshot(); s != 10 {
+		t.Errorf("expected a capacity of 10, got %d", s)
+	}
+}
+
+func TestTimed(t *testing.T) {
+	timer := instruments.NewTimer(-1)
+	c := make(chan Timed[string], 1)
+	c <- Enqueue("job")
+	time.Sleep(10 * time.Millisecond)
+	if v := (<-c).Dequeue(timer); v != "job" {
+		t.Errorf("expected job, got %s", v)
+	}
+	s := timer.Snapshot()
+	if len(s) != 1 || s[0] < 10 {
+		t.Errorf("expected a time in queue of at least 10ms, got %v", s)
+	}
+}
+
+func ExampleEnqueue() {
+	timer := instruments.NewTimer(-1)
+	jobs := make(chan Timed[string], 10)
+	jobs <- Enqueue("job")
+	close(jobs)
+	for j := range jobs {
+		j.Dequeue(timer)
+	}
+}
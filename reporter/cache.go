@@ -0,0 +1,71 @@
+package reporter
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Cache caches values built from the instruments of a registry by key,
+// such as the instruments of each route of an HTTP server.
+// The key is the string of the tags the value instruments are registered with,
+// in addition to the registry own tags, as returned by Tags.String.
+// A value is evicted whenever one of the instruments registered with its tags
+// is removed from the registry, for instance once expired or reset by a snapshot,
+// so that it is built again and its instruments registered again.
+type Cache[T any] struct {
+	// removals counts removed instruments, first for 64-bit alignment.
+	removals    uint64
+	r           *Registry
+	m           sync.Map
+	unsubscribe func()
+}
+
+// NewCache creates a new Cache of values built from the instruments of the registry.
+func NewCache[T any](r *Registry) *Cache[T] {
+	c := &Cache[T]{r: r}
+	c.unsubscribe = r.Subscribe(c.removed)
+	return c
+}
+
+// removed evicts the value built from the removed instrument.
+func (c *Cache[T]) removed(e Event) {
+	if e.Type != Removed {
+		return
+	}
+	atomic.AddUint64(&c.removals, 1)
+	c.m.Delete(c.key(e.Entry.Tags))
+}
+
+// key returns the string of the tags which are not part of the registry own tags.
+func (c *Cache[T]) key(tags Tags) string {
+	var k Tags
+	for n, v := range tags {
+		if w, present := c.r.tags[n]; present && w == v {
+			continue
+		}
+		if k == nil {
+			k = make(Tags)
+		}
+		k[n] = v
+	}
+	return k.String()
+}
+
+// Get returns the value cached under key, caching the value returned by f if there is none.
+func (c *Cache[T]) Get(key string, f func() T) T {
+	if v, ok := c.m.Load(key); ok {
+		return v.(T)
+	}
+	removals := atomic.LoadUint64(&c.removals)
+	v, loaded := c.m.LoadOrStore(key, f())
+	if !loaded && atomic.LoadUint64(&c.removals) != removals {
+		// An instrument was removed while the value was built, which may be one of its own.
+		c.m.CompareAndDelete(key, v)
+	}
+	return v.(T)
+}
+
+// Close stops evicting values, releasing the cache subscription to the registry.
+func (c *Cache[T]) Close() {
+	c.unsubscribe()
+}
@@ -0,0 +1,86 @@
+package reporter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+func TestCache(t *testing.T) {
+	r := NewRegistry()
+	c := NewCache[*instruments.Counter](r)
+	defer c.Close()
+	get := func(key string) *instruments.Counter {
+		tags := Tags{"key": key}
+		return c.Get(tags.String(), func() *instruments.Counter {
+			return Instrument(r.WithTags(tags), "count", instruments.NewCounter)
+		})
+	}
+	a, b := get("a"), get("b")
+	if get("a") != a || get("b") != b {
+		t.Fatal("cached value not returned")
+	}
+	r.WithTags(Tags{"key": "a"}).Unregister("count")
+	if get("b") != b {
+		t.Fatal("value of another key should be kept")
+	}
+	if a2 := get("a"); a2 == a || r.WithTags(Tags{"key": "a"}).Get("count") != a2 {
+		t.Fatal("value should be built and registered again once removed")
+	}
+	r.Snapshot()
+	if get("b") == b {
+		t.Fatal("value should be built again once reset")
+	}
+}
+
+func TestCacheScope(t *testing.T) {
+	r := NewRegistry()
+	db := r.Sub("db").WithTags(Tags{"host": "a"})
+	c := NewCache[*instruments.Counter](db)
+	defer c.Close()
+	get := func() *instruments.Counter {
+		tags := Tags{"table": "users"}
+		return c.Get(tags.String(), func() *instruments.Counter {
+			return Instrument(db.WithTags(tags), "count", instruments.NewCounter)
+		})
+	}
+	a := get()
+	r.Register("other", instruments.NewCounter())
+	r.Unregister("other")
+	if get() != a {
+		t.Fatal("value should be kept when removing instruments out of the cache scope")
+	}
+	db.WithTags(Tags{"table": "users"}).Unregister("count")
+	if get() == a {
+		t.Fatal("value should be built again once removed")
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	r := NewRegistry()
+	c := NewCache[*instruments.Counter](r)
+	defer c.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Get("", func() *instruments.Counter {
+					return Instrument(r, "count", instruments.NewCounter)
+				}).Update(1)
+				if j%10 == 0 {
+					r.Unregister("count")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	v := c.Get("", func() *instruments.Counter {
+		return Instrument(r, "count", instruments.NewCounter)
+	})
+	if v != r.Get("count") {
+		t.Fatal("cached value should be registered")
+	}
+}
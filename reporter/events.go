@@ -0,0 +1,110 @@
+package reporter
+
+// EventType is the type of a registry event.
+type EventType int
+
+const (
+	// Registered is the type of events sent when an instrument is registered.
+	Registered EventType = iota
+	// Replaced is the type of events sent when an instrument replaces another one.
+	Replaced
+	// Removed is the type of events sent when an instrument is unregistered,
+	// reset by a snapshot or expired.
+	Removed
+)
+
+var eventTypes = []string{"registered", "replaced", "removed"}
+
+// String returns the event type name.
+func (t EventType) String() string {
+	if t < 0 || int(t) >= len(eventTypes) {
+		return "unknown"
+	}
+	return eventTypes[t]
+}
+
+// Event describes a change of a registry.
+type Event struct {
+	Type  EventType
+	Entry Entry
+}
+
+type subscriber struct {
+	r *Registry
+	f func(Event)
+}
+
+type notification struct {
+	sub   *subscriber
+	event Event
+}
+
+// Subscribe calls f for every change affecting an instrument visible from the registry,
+// until the returned function is called. It is called once the registry is unlocked,
+// by the goroutine changing the registry or by the one already delivering events,
+// so that events are delivered one at a time, in the order of the changes.
+func (r *Registry) Subscribe(f func(Event)) func() {
+	s := &subscriber{r: r, f: f}
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	r.s.subscribers = append(r.s.subscribers, s)
+	return func() {
+		r.s.m.Lock()
+		defer r.s.m.Unlock()
+		for i, sub := range r.s.subscribers {
+			if sub == s {
+				r.s.subscribers = append(r.s.subscribers[:i:i], r.s.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notify queues an event for the subscribers of the entry.
+// It must be called with the store lock held.
+func (s *store) notify(t EventType, e *entry) {
+	for _, sub := range s.subscribers {
+		if sub.r.contains(e) {
+			s.pending = append(s.pending, notification{
+				sub:   sub,
+				event: Event{Type: t, Entry: e.export()},
+			})
+		}
+	}
+}
+
+// unlock releases the store lock, then sends the queued events accepted by the
+// subscribers filter, unless another goroutine is already sending them.
+func (s *store) unlock() {
+	if s.delivering {
+		s.m.Unlock()
+		return
+	}
+	s.delivering = true
+	for len(s.pending) > 0 {
+		pending := s.pending
+		s.pending = nil
+		s.m.Unlock()
+		s.deliver(pending)
+		s.m.Lock()
+	}
+	s.delivering = false
+	s.m.Unlock()
+}
+
+// deliver sends the events, letting another goroutine send the next ones if a subscriber panics.
+func (s *store) deliver(pending []notification) {
+	defer func() {
+		if v := recover(); v != nil {
+			s.m.Lock()
+			s.delivering = false
+			s.m.Unlock()
+			panic(v)
+		}
+	}()
+	for _, n := range pending {
+		if n.sub.r.accepts(n.event.Entry) {
+			n.sub.f(n.event)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package reporter
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+func TestSubscribe(t *testing.T) {
+	r := NewRegistry()
+	var events []string
+	unsubscribe := r.Subscribe(func(e Event) {
+		events = append(events, e.Type.String()+" "+e.Entry.Name)
+		// The registry must be unlocked
+		r.Size()
+	})
+	var scoped []string
+	r.Sub("db").Subscribe(func(e Event) {
+		scoped = append(scoped, e.Type.String()+" "+e.Entry.Name)
+	})
+
+	c := instruments.NewCounter()
+	r.Register("foo", c)
+	r.Register("foo", instruments.NewCounter())
+	if i := r.Replace("foo", instruments.NewGauge(0)); i == c {
+		t.Fatal("instrument not replaced")
+	}
+	r.Sub("db").Register("query", instruments.NewTimer(-1))
+	r.Unregister("foo")
+	r.Snapshot()
+	unsubscribe()
+	r.Register("bar", instruments.NewCounter())
+
+	expected := []string{
+		"registered foo",
+		"replaced foo",
+		"registered db.query",
+		"removed foo",
+		"removed db.query",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("wants %v got %v", expected, events)
+	}
+	expected = []string{"registered db.query", "removed db.query"}
+	if !reflect.DeepEqual(scoped, expected) {
+		t.Errorf("wants %v got %v", expected, scoped)
+	}
+}
+
+func TestSubscribeOrder(t *testing.T) {
+	r := NewRegistry()
+	registered := make(map[string]bool)
+	var errs []string
+	r.Subscribe(func(e Event) {
+		switch e.Type {
+		case Registered:
+			registered[e.Entry.Name] = true
+		case Removed:
+			if !registered[e.Entry.Name] {
+				errs = append(errs, e.Entry.Name)
+			}
+			delete(registered, e.Entry.Name)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("foo.%d", i)
+			for j := 0; j < 100; j++ {
+				r.Register(name, instruments.NewCounter())
+				r.Unregister(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) != 0 || len(registered) != 0 {
+		t.Errorf("events delivered out of order: %v %v", errs, registered)
+	}
+}
+
+func TestSubscribeNested(t *testing.T) {
+	r := NewRegistry()
+	var events []string
+	r.Subscribe(func(e Event) {
+		events = append(events, e.Type.String()+" "+e.Entry.Name)
+		if e.Entry.Name == "foo" && e.Type == Registered {
+			r.Register("bar", instruments.NewCounter())
+		}
+	})
+	r.Register("foo", instruments.NewCounter())
+	expected := []string{"registered foo", "registered bar"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("wants %v got %v", expected, events)
+	}
+}
@@ -0,0 +1,85 @@
+package reporter
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Filter reports whether an instrument should be visible.
+type Filter func(e Entry) bool
+
+// Include returns a filter accepting instruments whose name matches any of the glob patterns,
+// where '*' matches any sequence of characters and '?' any single character.
+func Include(patterns ...string) Filter {
+	re := globs(patterns)
+	return func(e Entry) bool {
+		return re.MatchString(e.Name)
+	}
+}
+
+// Exclude returns a filter rejecting instruments whose name matches any of the glob patterns.
+func Exclude(patterns ...string) Filter {
+	return Not(Include(patterns...))
+}
+
+// IncludeRegexp returns a filter accepting instruments whose name matches the regular expression.
+func IncludeRegexp(re *regexp.Regexp) Filter {
+	return func(e Entry) bool {
+		return re.MatchString(e.Name)
+	}
+}
+
+// ExcludeRegexp returns a filter rejecting instruments whose name matches the regular expression.
+func ExcludeRegexp(re *regexp.Regexp) Filter {
+	return Not(IncludeRegexp(re))
+}
+
+// IncludeTags returns a filter accepting instruments having all the given tags.
+func IncludeTags(tags Tags) Filter {
+	return func(e Entry) bool {
+		return e.Tags.Contains(tags)
+	}
+}
+
+// Not returns a filter accepting the instruments rejected by f.
+func Not(f Filter) Filter {
+	return func(e Entry) bool {
+		return !f(e)
+	}
+}
+
+// All returns a filter accepting instruments accepted by all the given filters.
+func All(filters ...Filter) Filter {
+	return func(e Entry) bool {
+		for _, f := range filters {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any returns a filter accepting instruments accepted by any of the given filters.
+func Any(filters ...Filter) Filter {
+	return func(e Entry) bool {
+		for _, f := range filters {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// globs compiles glob patterns into a single anchored regular expression.
+func globs(patterns []string) *regexp.Regexp {
+	exprs := make([]string, len(patterns))
+	for i, p := range patterns {
+		p = regexp.QuoteMeta(p)
+		p = strings.ReplaceAll(p, `\*`, ".*")
+		p = strings.ReplaceAll(p, `\?`, ".")
+		exprs[i] = p
+	}
+	return regexp.MustCompile("^(?:" + strings.Join(exprs, "|") + ")$")
+}
@@ -0,0 +1,83 @@
+package reporter
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+func TestFilter(t *testing.T) {
+	r := NewRegistry()
+	r.Register("business.signups", instruments.NewCounter())
+	r.Register("business.orders.total", instruments.NewCounter())
+	r.Register("runtime.heap", instruments.NewGauge(0))
+	r.WithTags(Tags{"route": "/"}).Register("http.requests", instruments.NewRate())
+	r.WithTags(Tags{"route": "/a"}).Register("http.requests", instruments.NewRate())
+
+	var filterTests = []struct {
+		filter Filter
+		size   int
+	}{
+		{Include("business.*"), 2},
+		{Include("business.?????", "runtime.*"), 1},
+		{Exclude("business.*"), 3},
+		{IncludeRegexp(regexp.MustCompile(`^business\.[a-z]+$`)), 1},
+		{ExcludeRegexp(regexp.MustCompile(`^http`)), 3},
+		{IncludeTags(Tags{"route": "/a"}), 1},
+		{Any(Include("runtime.*"), IncludeTags(Tags{"route": "/"})), 2},
+	}
+	for i, ft := range filterTests {
+		f := r.Filter(ft.filter)
+		if size := f.Size(); size != ft.size {
+			t.Errorf("%d: wants %d instruments got %d", i, ft.size, size)
+		}
+		if size := len(f.Instruments()); size != ft.size {
+			t.Errorf("%d: wants %d instruments got %d", i, ft.size, size)
+		}
+	}
+
+	f := r.Filter(Include("business.*")).Filter(Exclude("*.total"))
+	if entries := f.Entries(); len(entries) != 1 || entries[0].Name != "business.signups" {
+		t.Errorf("unexpected filtered entries %v", entries)
+	}
+	f.Register("other", instruments.NewCounter())
+	if r.Get("other") == nil || f.Size() != 1 {
+		t.Error("registration should not be filtered")
+	}
+}
+
+func TestFilterLookup(t *testing.T) {
+	r := NewRegistry()
+	r.Register("business.signups", instruments.NewCounter())
+	r.Register("runtime.heap", instruments.NewGauge(0))
+	f := r.Filter(Include("business.*"))
+	if f.Get("runtime.heap") != nil || f.Get("business.signups") == nil {
+		t.Error("lookups should be filtered")
+	}
+	f.Unregister("runtime.heap")
+	if r.Get("runtime.heap") == nil {
+		t.Error("filtered instrument should not be unregistered")
+	}
+	if snapshot := f.Snapshot(); len(snapshot) != 1 || r.Size() != 1 {
+		t.Errorf("snapshot should only reset the filtered instruments, got %v", snapshot)
+	}
+}
+
+func TestFilterRegistry(t *testing.T) {
+	r := NewRegistry()
+	// Filters may use the registry without deadlocking.
+	f := r.Filter(func(e Entry) bool {
+		return r.Get("enabled") != nil
+	})
+	var events int
+	f.Subscribe(func(Event) { events++ })
+	r.Register("business.signups", instruments.NewCounter())
+	r.Register("enabled", instruments.NewGauge(1))
+	if events != 1 {
+		t.Errorf("expected 1 event, got %d", events)
+	}
+	if f.Size() != 2 || len(f.Entries()) != 2 || len(f.Snapshot()) != 2 {
+		t.Error("filter should accept all instruments once enabled")
+	}
+}
@@ -0,0 +1,78 @@
+package reporter
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/heroku/instruments"
+)
+
+// Summary is the JSON representation of an instrument reading.
+type Summary struct {
+	Name  string `json:"name"`
+	Tags  Tags   `json:"tags,omitempty"`
+	Kind  string `json:"kind"`
+	Unit  string `json:"unit,omitempty"`
+	Value *int64 `json:"value,omitempty"`
+	// Sample summarizes the sample of Sample instruments.
+	Sample *SampleSummary `json:"sample,omitempty"`
+}
+
+// SampleSummary summarizes a sample.
+type SampleSummary struct {
+	Count  int     `json:"count"`
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median int64   `json:"median"`
+	P95    int64   `json:"p95"`
+	P99    int64   `json:"p99"`
+}
+
+// Summarize returns the summaries of the readings, keyed by their name qualified by their tags.
+func Summarize(rs Readings) map[string]Summary {
+	m := make(map[string]Summary, len(rs))
+	for k, r := range rs {
+		s := Summary{
+			Name: r.Name,
+			Tags: r.Tags,
+			Kind: r.Kind.String(),
+			Unit: r.Unit,
+		}
+		if r.Sample != nil {
+			s.Sample = &SampleSummary{
+				Count:  len(r.Sample),
+				Min:    instruments.Min(r.Sample),
+				Max:    instruments.Max(r.Sample),
+				Mean:   instruments.Mean(r.Sample),
+				Median: instruments.Quantile(r.Sample, 0.5),
+				P95:    instruments.Quantile(r.Sample, 0.95),
+				P99:    instruments.Quantile(r.Sample, 0.99),
+			}
+		} else {
+			v := r.Value
+			s.Value = &v
+		}
+		m[k] = s
+	}
+	return m
+}
+
+// Handler returns an http.Handler serving the current values of the registry
+// instruments as JSON, without resetting them.
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Summarize(r.Peek()))
+	})
+}
+
+// Publish publishes the current values of the registry instruments
+// in the expvar package under the given name, served under /debug/vars.
+// Like expvar.Publish, it panics if the name is already in use.
+func Publish(name string, r *Registry) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return Summarize(r.Peek())
+	}))
+}
@@ -0,0 +1,63 @@
+package reporter
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(3)
+	timer := r.WithTags(Tags{"route": "/"}).Register("latency", instruments.NewTimer(-1)).(*instruments.Timer)
+	for i := 1; i <= 100; i++ {
+		timer.Update(time.Duration(i) * time.Millisecond)
+	}
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		Handler(r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected application/json, got %s", ct)
+		}
+		var m map[string]Summary
+		if err := json.NewDecoder(w.Body).Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if s := m["requests"]; s.Kind != "counter" || s.Value == nil || *s.Value != 3 {
+			t.Errorf("unexpected requests summary %+v", s)
+		}
+		s := m["latency[route:/]"]
+		if s.Unit != "ms" || s.Tags["route"] != "/" || s.Sample == nil {
+			t.Fatalf("unexpected latency summary %+v", s)
+		}
+		expected := SampleSummary{Count: 100, Min: 1, Max: 100, Mean: 50.5, Median: 51, P95: 96, P99: 100}
+		if *s.Sample != expected {
+			t.Errorf("expected %+v, got %+v", expected, *s.Sample)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(3)
+	Publish("instruments_test", r)
+
+	var m map[string]Summary
+	if err := json.Unmarshal([]byte(expvar.Get("instruments_test").String()), &m); err != nil {
+		t.Fatal(err)
+	}
+	if s := m["requests"]; s.Value == nil || *s.Value != 3 {
+		t.Errorf("unexpected requests summary %+v", s)
+	}
+}
+
+func ExampleHandler() {
+	registry := NewRegistry()
+	http.Handle("/metrics", Handler(registry))
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/heroku/instruments"
@@ -75,23 +76,9 @@ func Librato(email, token, source string, r *Registry, d time.Duration) {
 	for now := range time.Tick(d) {
 		b := batch{
 			Source:      source,
-			Gauges:      []map[string]interface{}{},
 			MeasureTime: now.Unix(),
 		}
-		for k, m := range r.Instruments() {
-			var s int64
-			switch i := m.(type) {
-			case instruments.Discrete:
-				s = i.Snapshot()
-			case instruments.Sample:
-				s = instruments.Quantile(i.Snapshot(), 0.95)
-			}
-			b.Gauges = append(b.Gauges, map[string]interface{}{
-				"name":   k,
-				"value":  float64(s),
-				"period": d.Seconds(),
-			})
-		}
+		b.Gauges = append(b.Gauges, libratoGauges(r, source, d)...)
 
 		err := client.Post(b)
 		if err != nil {
@@ -99,3 +86,37 @@ func Librato(email, token, source string, r *Registry, d time.Duration) {
 		}
 	}
 }
+
+// libratoGauges snapshots the registry instruments into Librato gauges,
+// reporting tagged instruments under a source qualified by their tags.
+func libratoGauges(r *Registry, source string, d time.Duration) []map[string]interface{} {
+	gauges := []map[string]interface{}{}
+	for _, e := range r.Entries() {
+		var s int64
+		switch i := e.Instrument.(type) {
+		case instruments.Discrete:
+			s = i.Snapshot()
+		case instruments.Sample:
+			s = instruments.Quantile(i.Snapshot(), 0.95)
+		}
+		g := map[string]interface{}{
+			"name":   LibratoName(e.Name),
+			"value":  float64(s),
+			"period": d.Seconds(),
+		}
+		if len(e.Tags) > 0 {
+			g["source"] = libratoSource(source, e.Tags)
+		}
+		gauges = append(gauges, g)
+	}
+	return gauges
+}
+
+// libratoSource qualifies the source with the tags, as "source.k1:v1.k2:v2".
+func libratoSource(source string, tags Tags) string {
+	parts := []string{source}
+	for _, k := range tags.Keys() {
+		parts = append(parts, k+":"+tags[k])
+	}
+	return LibratoName(strings.Join(parts, "."))
+}
@@ -1,6 +1,35 @@
 package reporter
 
-import "time"
+import (
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+func TestLibratoGauges(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(1)
+	r.WithTags(Tags{"route": "/users", "method": "GET"}).Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(2)
+
+	gauges := libratoGauges(r, "web.1", time.Minute)
+	if len(gauges) != 2 {
+		t.Fatalf("expected 2 gauges, got %d", len(gauges))
+	}
+	sources := map[interface{}]float64{}
+	for _, g := range gauges {
+		if g["name"] != "requests" {
+			t.Errorf("expected requests name, got %v", g["name"])
+		}
+		sources[g["source"]] = g["value"].(float64)
+	}
+	if sources[nil] != 1 {
+		t.Errorf("expected untagged gauge without source, got %v", sources)
+	}
+	if sources["web.1.method:GET.route:_users"] != 2 {
+		t.Errorf("expected tagged gauge under a qualified source, got %v", sources)
+	}
+}
 
 func ExampleLibrato() {
 	registry := NewRegistry()
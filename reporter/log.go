@@ -10,12 +10,17 @@ import (
 	"github.com/heroku/instruments"
 )
 
+// maxLineLength is the maximum length of lines logged by LogHeroku,
+// leaving room for log prefixes under the 10000 bytes Logplex limit.
+const maxLineLength = 8192
+
 // Log logs metrics using logfmt every given duration.
 func Log(source string, r *Registry, d time.Duration) {
 	for range time.Tick(d) {
 		var parts []string
-		for k, m := range r.Instruments() {
-			switch i := m.(type) {
+		for _, e := range r.Entries() {
+			k := LogfmtName(e.Key())
+			switch i := e.Instrument.(type) {
 			case instruments.Discrete:
 				s := i.Snapshot()
 				parts = append(parts, fmt.Sprintf("sample#%s=%d", k, s))
@@ -27,3 +32,62 @@ func Log(source string, r *Registry, d time.Duration) {
 		log.Println(fmt.Sprintf("source=%s", source), strings.Join(parts, " "))
 	}
 }
+
+// LogHeroku logs metrics using the Heroku log-runtime-metrics conventions every given duration:
+// counters and rates are logged as count#, gauges as sample#, and samples as
+// measure# of their median, 95th and 99th percentiles.
+// Lines are split to stay under the Logplex line length limit.
+func LogHeroku(source string, r *Registry, d time.Duration) {
+	for range time.Tick(d) {
+		for _, line := range splitLines(fmt.Sprintf("source=%s", source), herokuParts(r), maxLineLength) {
+			log.Println(line)
+		}
+	}
+}
+
+// herokuParts snapshots the registry instruments into Heroku logfmt metrics.
+func herokuParts(r *Registry) []string {
+	var parts []string
+	for _, e := range r.Entries() {
+		switch i := e.Instrument.(type) {
+		case instruments.Discrete:
+			s := i.Snapshot()
+			switch e.Metadata.Kind {
+			case KindCounter, KindRate:
+				parts = append(parts, fmt.Sprintf("count#%s=%d", LogfmtName(e.Key()), s))
+			default:
+				parts = append(parts, fmt.Sprintf("sample#%s=%d%s", LogfmtName(e.Key()), s, e.Metadata.Unit))
+			}
+		case instruments.Sample:
+			s := i.Snapshot()
+			if len(s) == 0 {
+				continue
+			}
+			for _, q := range []struct {
+				name string
+				q    float64
+			}{{"median", 0.5}, {"p95", 0.95}, {"p99", 0.99}} {
+				k := LogfmtName(e.Name + "." + q.name + e.Tags.String())
+				parts = append(parts, fmt.Sprintf("measure#%s=%d%s", k, instruments.Quantile(s, q.q), e.Metadata.Unit))
+			}
+		}
+	}
+	return parts
+}
+
+// splitLines joins parts into lines starting with prefix, no longer than limit when possible.
+func splitLines(prefix string, parts []string, limit int) []string {
+	var lines []string
+	line := prefix
+	for _, p := range parts {
+		if line != prefix && len(line)+1+len(p) > limit {
+			lines = append(lines, line)
+			line = prefix
+		}
+		line += " " + p
+	}
+	if line != prefix {
+		lines = append(lines, line)
+	}
+	return lines
+}
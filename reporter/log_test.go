@@ -1,8 +1,64 @@
 package reporter
 
-import "time"
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+func TestHerokuParts(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(3)
+	r.Register("memory", instruments.NewGauge(42))
+	r.Describe("memory", Metadata{Unit: "MB"})
+	timer := r.WithTags(Tags{"route": "/"}).Register("latency", instruments.NewTimer(-1)).(*instruments.Timer)
+	for i := 1; i <= 100; i++ {
+		timer.Update(time.Duration(i) * time.Millisecond)
+	}
+	r.Register("empty", instruments.NewReservoir(-1))
+
+	parts := herokuParts(r)
+	sort.Strings(parts)
+	expected := []string{
+		"count#requests=3",
+		"measure#latency.median[route:/]=51ms",
+		"measure#latency.p95[route:/]=96ms",
+		"measure#latency.p99[route:/]=100ms",
+		"sample#memory=42MB",
+	}
+	if !reflect.DeepEqual(parts, expected) {
+		t.Errorf("expected %v, got %v", expected, parts)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	var tests = []struct {
+		parts []string
+		limit int
+		lines []string
+	}{
+		{nil, 20, nil},
+		{[]string{"count#a=1", "count#b=2"}, 30, []string{"source=s count#a=1 count#b=2"}},
+		{[]string{"count#a=1", "count#b=2"}, 20, []string{"source=s count#a=1", "source=s count#b=2"}},
+		{[]string{"count#toolong=1"}, 10, []string{"source=s count#toolong=1"}},
+	}
+	for _, test := range tests {
+		lines := splitLines("source=s", test.parts, test.limit)
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("expected %q, got %q", test.lines, lines)
+		}
+	}
+}
 
 func ExampleLog() {
 	registry := NewRegistry()
 	go Log("source", registry, time.Minute)
 }
+
+func ExampleLogHeroku() {
+	registry := NewRegistry()
+	go LogHeroku("source", registry, time.Minute)
+}
@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+// Kind is the semantic kind of an instrument.
+type Kind int
+
+const (
+	// KindUnknown is the kind of instruments which can't be inferred.
+	KindUnknown Kind = iota
+	// KindCounter is the kind of instruments counting events over an interval.
+	KindCounter
+	// KindRate is the kind of instruments measuring events per unit of time.
+	KindRate
+	// KindGauge is the kind of instruments tracking a current value.
+	KindGauge
+	// KindDistribution is the kind of instruments sampling values.
+	KindDistribution
+)
+
+var kinds = []string{"unknown", "counter", "rate", "gauge", "distribution"}
+
+// String returns the kind name.
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kinds) {
+		return kinds[KindUnknown]
+	}
+	return kinds[k]
+}
+
+// Metadata describes an instrument.
+type Metadata struct {
+	// Description is a human readable description of the instrument.
+	Description string
+	// Unit is the unit of the instrument values, such as "ms", "bytes" or "1/s".
+	Unit string
+	// Kind is the semantic kind of the instrument.
+	Kind Kind
+}
+
+// merge returns the metadata completed by the defaults.
+func (md Metadata) merge(defaults Metadata) Metadata {
+	if md.Description == "" {
+		md.Description = defaults.Description
+	}
+	if md.Unit == "" {
+		md.Unit = defaults.Unit
+	}
+	if md.Kind == KindUnknown {
+		md.Kind = defaults.Kind
+	}
+	return md
+}
+
+// inferMetadata returns the metadata of the built-in instruments.
+func inferMetadata(v interface{}) Metadata {
+	switch i := v.(type) {
+	case *instruments.Counter:
+		return Metadata{Kind: KindCounter}
+	case *instruments.Rate:
+		return Metadata{Kind: KindRate, Unit: perUnit(i.Unit())}
+	case *instruments.Derive:
+		return Metadata{Kind: KindRate, Unit: perUnit(i.Unit())}
+	case *instruments.Gauge:
+		return Metadata{Kind: KindGauge}
+	case *instruments.Reservoir:
+		return Metadata{Kind: KindDistribution}
+	case *instruments.Timer:
+		return Metadata{Kind: KindDistribution, Unit: durationUnit(i.Unit())}
+	}
+	return Metadata{}
+}
+
+func durationUnit(d time.Duration) string {
+	switch d {
+	case time.Nanosecond:
+		return "ns"
+	case time.Microsecond:
+		return "us"
+	case time.Millisecond:
+		return "ms"
+	case time.Second:
+		return "s"
+	case time.Minute:
+		return "min"
+	case time.Hour:
+		return "h"
+	}
+	return d.String()
+}
+
+func perUnit(d time.Duration) string {
+	return "1/" + durationUnit(d)
+}
@@ -0,0 +1,59 @@
+package reporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+var metadataTests = []struct {
+	instrument interface{}
+	md         Metadata
+}{
+	{instruments.NewCounter(), Metadata{Kind: KindCounter}},
+	{instruments.NewRate(), Metadata{Kind: KindRate, Unit: "1/s"}},
+	{instruments.NewDeriveScale(0, time.Minute), Metadata{Kind: KindRate, Unit: "1/min"}},
+	{instruments.NewGauge(0), Metadata{Kind: KindGauge}},
+	{instruments.NewReservoir(-1), Metadata{Kind: KindDistribution}},
+	{instruments.NewTimer(-1), Metadata{Kind: KindDistribution, Unit: "ms"}},
+}
+
+func TestInferMetadata(t *testing.T) {
+	for i, mt := range metadataTests {
+		r := NewRegistry()
+		r.Register("foo", mt.instrument)
+		if md := r.Metadata("foo"); md != mt.md {
+			t.Errorf("%d: wants %+v got %+v", i, mt.md, md)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", instruments.NewTimer(-1))
+	r.Describe("foo", Metadata{Description: "Processing time."})
+	md := r.Metadata("foo")
+	if md.Description != "Processing time." || md.Unit != "ms" || md.Kind != KindDistribution {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+	entries := r.Entries()
+	if len(entries) != 1 || entries[0].Metadata != md {
+		t.Errorf("metadata not exposed in entries: %+v", entries)
+	}
+	if md := r.Metadata("bar"); md != (Metadata{}) {
+		t.Errorf("unexpected metadata for missing instrument %+v", md)
+	}
+	r.Describe("foo", Metadata{Description: "Processing time.", Unit: "s"})
+	r.Replace("foo", instruments.NewTimer(-1))
+	if md := r.Metadata("foo"); md.Unit != "s" {
+		t.Errorf("metadata should be kept by a similar instrument %+v", md)
+	}
+	r.Replace("foo", instruments.NewCounter())
+	if md := r.Metadata("foo"); md != (Metadata{Description: "Processing time.", Kind: KindCounter}) {
+		t.Errorf("metadata not reset by another type of instrument %+v", md)
+	}
+	if s := KindRate.String(); s != "rate" {
+		t.Errorf("unexpected kind name %q", s)
+	}
+}
@@ -0,0 +1,103 @@
+package reporter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidName is returned when a name policy rejects an instrument name.
+var ErrInvalidName = errors.New("reporter: invalid instrument name")
+
+// NamePolicy validates an instrument name on registration,
+// returning the name to register or an error wrapping ErrInvalidName.
+type NamePolicy func(name string) (string, error)
+
+// invalid reports whether the rune would corrupt logfmt output.
+func invalid(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || r == '=' || r == '"'
+}
+
+// NormalizeNames is a NamePolicy replacing whitespaces, control characters,
+// '=' and '"' with underscores. It rejects empty names.
+func NormalizeNames(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: empty name", ErrInvalidName)
+	}
+	return replace(name, invalid, '_'), nil
+}
+
+// ValidateNames is a NamePolicy rejecting empty names and names containing
+// whitespaces, control characters, '=' or '"'.
+func ValidateNames(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("%w: empty name", ErrInvalidName)
+	}
+	if i := strings.IndexFunc(name, invalid); i >= 0 {
+		return "", fmt.Errorf("%w: %q contains %q", ErrInvalidName, name, name[i:i+1])
+	}
+	return name, nil
+}
+
+// LogfmtName translates a name to a valid logfmt key.
+func LogfmtName(name string) string {
+	return replace(name, invalid, '_')
+}
+
+// PrometheusName translates a name to the Prometheus metric names rules,
+// replacing invalid characters with underscores.
+func PrometheusName(name string) string {
+	name = replace(name, func(r rune) bool {
+		return !(r == '_' || r == ':' || isASCIILetter(r) || isASCIIDigit(r))
+	}, '_')
+	if name == "" || isASCIIDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// GraphiteName translates a name to a Graphite metric path, preserving dots
+// and replacing other unsupported characters with underscores.
+func GraphiteName(name string) string {
+	return replace(name, func(r rune) bool {
+		return !(r == '.' || r == '_' || r == '-' || isASCIILetter(r) || isASCIIDigit(r))
+	}, '_')
+}
+
+// StatsDName translates a name to a StatsD bucket, replacing the protocol
+// separators ':', '|' and '@' and whitespaces with underscores.
+func StatsDName(name string) string {
+	return replace(name, func(r rune) bool {
+		return r == ':' || r == '|' || r == '@' || unicode.IsSpace(r) || unicode.IsControl(r)
+	}, '_')
+}
+
+// LibratoName translates a name to a Librato metric name,
+// replacing unsupported characters with underscores and truncating it to 255 characters.
+func LibratoName(name string) string {
+	name = replace(name, func(r rune) bool {
+		return !(r == '.' || r == ':' || r == '-' || r == '_' || isASCIILetter(r) || isASCIIDigit(r))
+	}, '_')
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+func replace(s string, f func(rune) bool, c rune) string {
+	return strings.Map(func(r rune) rune {
+		if f(r) {
+			return c
+		}
+		return r
+	}, s)
+}
+
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
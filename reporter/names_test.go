@@ -0,0 +1,99 @@
+package reporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+var namesTests = []struct {
+	name       string
+	logfmt     string
+	prometheus string
+	graphite   string
+	statsd     string
+	librato    string
+}{
+	{
+		name:       "http.requests",
+		logfmt:     "http.requests",
+		prometheus: "http_requests",
+		graphite:   "http.requests",
+		statsd:     "http.requests",
+		librato:    "http.requests",
+	},
+	{
+		name:       "db query=time",
+		logfmt:     "db_query_time",
+		prometheus: "db_query_time",
+		graphite:   "db_query_time",
+		statsd:     "db_query=time",
+		librato:    "db_query_time",
+	},
+	{
+		name:       "requests[route:/a]",
+		logfmt:     "requests[route:/a]",
+		prometheus: "requests_route:_a_",
+		graphite:   "requests_route__a_",
+		statsd:     "requests[route_/a]",
+		librato:    "requests_route:_a_",
+	},
+	{
+		name:       "5xx",
+		logfmt:     "5xx",
+		prometheus: "_5xx",
+		graphite:   "5xx",
+		statsd:     "5xx",
+		librato:    "5xx",
+	},
+}
+
+func TestNames(t *testing.T) {
+	for i, nt := range namesTests {
+		if s := LogfmtName(nt.name); s != nt.logfmt {
+			t.Errorf("%d: logfmt wants %q got %q", i, nt.logfmt, s)
+		}
+		if s := PrometheusName(nt.name); s != nt.prometheus {
+			t.Errorf("%d: prometheus wants %q got %q", i, nt.prometheus, s)
+		}
+		if s := GraphiteName(nt.name); s != nt.graphite {
+			t.Errorf("%d: graphite wants %q got %q", i, nt.graphite, s)
+		}
+		if s := StatsDName(nt.name); s != nt.statsd {
+			t.Errorf("%d: statsd wants %q got %q", i, nt.statsd, s)
+		}
+		if s := LibratoName(nt.name); s != nt.librato {
+			t.Errorf("%d: librato wants %q got %q", i, nt.librato, s)
+		}
+	}
+}
+
+func TestNamePolicy(t *testing.T) {
+	r := NewRegistry()
+	r.Register("queue time", instruments.NewTimer(-1))
+	if r.Get("queue_time") != nil || r.Get("queue time") == nil {
+		t.Fatal("name should be registered as is by default")
+	}
+	r.Unregister("queue time")
+
+	r.SetNamePolicy(NormalizeNames)
+	r.Register("processing time", instruments.NewTimer(-1))
+	if r.Get("processing_time") == nil || r.Get("processing time") == nil {
+		t.Fatal("name not normalized")
+	}
+
+	r.SetNamePolicy(ValidateNames)
+	if i := r.Register("a=b", instruments.NewCounter()); i != nil {
+		t.Fatal("invalid name registered")
+	}
+	if _, err := GetOrRegister(r, "a b", instruments.NewCounter); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected an invalid name, got %v", err)
+	}
+	if _, err := GetOrRegister(r, "", instruments.NewCounter); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected an invalid name, got %v", err)
+	}
+	if r.Size() != 1 {
+		t.Fatal("invalid names registered")
+	}
+}
@@ -0,0 +1,144 @@
+package reporter
+
+import (
+	"sort"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+// Reading is the value of an instrument at a point in time.
+type Reading struct {
+	Name string
+	Tags Tags
+	Kind Kind
+	Unit string
+	Time time.Time
+	// Value is the value of a Discrete instrument.
+	Value int64
+	// Sample is the sorted sample of a Sample instrument, nil for Discrete instruments.
+	Sample []int64
+}
+
+// Readings are instruments readings, keyed by their name qualified by their tags.
+type Readings map[string]Reading
+
+// Read returns the values of all instruments.
+// As reading instruments values resets them, reporters should use Read rather
+// than reading the instruments when several reporters need the same values.
+func (r *Registry) Read() Readings {
+	return r.read(false)
+}
+
+// Peek returns the values of all instruments without resetting them.
+// Instruments which can't be read without being reset are omitted.
+func (r *Registry) Peek() Readings {
+	return r.read(true)
+}
+
+func (r *Registry) read(peek bool) Readings {
+	now := time.Now()
+	entries := r.Entries()
+	rs := make(Readings, len(entries))
+	for _, e := range entries {
+		reading := Reading{
+			Name: e.Name,
+			Tags: e.Tags,
+			Kind: e.Metadata.Kind,
+			Unit: e.Metadata.Unit,
+			Time: now,
+		}
+		if peek {
+			switch i := e.Instrument.(type) {
+			case instruments.DiscretePeeker:
+				reading.Value = i.Peek()
+			case instruments.SamplePeeker:
+				reading.Sample = i.Peek()
+			default:
+				continue
+			}
+		} else {
+			switch i := e.Instrument.(type) {
+			case instruments.Discrete:
+				reading.Value = i.Snapshot()
+			case instruments.Sample:
+				reading.Sample = i.Snapshot()
+			}
+		}
+		rs[e.Key()] = reading
+	}
+	return rs
+}
+
+// Merge returns the readings of both rs and o, combining readings present in both:
+// values of counters and rates are summed, samples are merged, and the latest
+// reading is kept for other kinds of instruments.
+func (rs Readings) Merge(o Readings) Readings {
+	m := make(Readings, len(rs)+len(o))
+	for k, r := range rs {
+		m[k] = r
+	}
+	for k, r := range o {
+		p, present := m[k]
+		if present && p.Kind == r.Kind {
+			r = merge(p, r)
+		} else if present && p.Time.After(r.Time) {
+			r = p
+		}
+		m[k] = r
+	}
+	return m
+}
+
+func merge(a, b Reading) Reading {
+	latest := b
+	if a.Time.After(b.Time) {
+		latest = a
+	}
+	switch {
+	case a.Sample != nil || b.Sample != nil:
+		sample := make([]int64, 0, len(a.Sample)+len(b.Sample))
+		sample = append(sample, a.Sample...)
+		sample = append(sample, b.Sample...)
+		sort.Slice(sample, func(i, j int) bool { return sample[i] < sample[j] })
+		latest.Sample = sample
+	case a.Kind == KindCounter || a.Kind == KindRate:
+		latest.Value = a.Value + b.Value
+	}
+	return latest
+}
+
+// Diff returns the changes from rs to o: the readings only present in o,
+// and the readings of both whose value changed, holding the difference of
+// their values, or the sample of o for Sample instruments.
+// Readings only present in rs are ignored.
+func (rs Readings) Diff(o Readings) Readings {
+	d := make(Readings)
+	for k, r := range o {
+		p, present := rs[k]
+		switch {
+		case !present:
+			d[k] = r
+		case r.Sample != nil || p.Sample != nil:
+			if !equal(p.Sample, r.Sample) {
+				d[k] = r
+			}
+		case r.Value != p.Value:
+			r.Value -= p.Value
+			d[k] = r
+		}
+	}
+	return d
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package reporter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+func TestRead(t *testing.T) {
+	r := NewRegistry()
+	c := instruments.NewCounter()
+	c.Update(3)
+	tm := instruments.NewTimer(-1)
+	tm.Update(2 * time.Millisecond)
+	tm.Update(time.Millisecond)
+	r.Register("counter", c)
+	r.WithTags(Tags{"route": "/"}).Register("timer", tm)
+
+	rs := r.Read()
+	if reading := rs["counter"]; reading.Value != 3 || reading.Kind != KindCounter || reading.Time.IsZero() {
+		t.Errorf("unexpected counter reading %+v", reading)
+	}
+	reading := rs["timer[route:/]"]
+	if !reflect.DeepEqual(reading.Sample, []int64{1, 2}) || reading.Tags["route"] != "/" {
+		t.Errorf("unexpected timer reading %+v", reading)
+	}
+	if r.Size() != 2 {
+		t.Error("reading should not unregister instruments")
+	}
+	if rs := r.Read(); rs["counter"].Value != 0 {
+		t.Error("reading should reset instruments")
+	}
+}
+
+func TestPeek(t *testing.T) {
+	r := NewRegistry()
+	c := instruments.NewCounter()
+	c.Update(3)
+	tm := instruments.NewTimer(-1)
+	tm.Update(time.Millisecond)
+	r.Register("counter", c)
+	r.Register("timer", tm)
+	r.Register("custom", constant(1))
+
+	for i := 0; i < 2; i++ {
+		rs := r.Peek()
+		if rs["counter"].Value != 3 || rs["timer"].Unit != "ms" || len(rs["timer"].Sample) != 1 {
+			t.Errorf("unexpected readings %+v", rs)
+		}
+		if _, ok := rs["custom"]; ok {
+			t.Error("instruments which can't be peeked should be omitted")
+		}
+	}
+}
+
+func TestReadingsMerge(t *testing.T) {
+	t0 := time.Now()
+	t1 := t0.Add(time.Second)
+	a := Readings{
+		"requests": {Name: "requests", Kind: KindCounter, Time: t0, Value: 2},
+		"heap":     {Name: "heap", Kind: KindGauge, Time: t1, Value: 20},
+		"time":     {Name: "time", Kind: KindDistribution, Time: t0, Sample: []int64{1, 5}},
+		"a":        {Name: "a", Kind: KindGauge, Time: t0, Value: 1},
+	}
+	b := Readings{
+		"requests": {Name: "requests", Kind: KindCounter, Time: t1, Value: 3},
+		"heap":     {Name: "heap", Kind: KindGauge, Time: t0, Value: 10},
+		"time":     {Name: "time", Kind: KindDistribution, Time: t1, Sample: []int64{2, 3}},
+		"b":        {Name: "b", Kind: KindGauge, Time: t0, Value: 2},
+	}
+	m := a.Merge(b)
+	if len(m) != 5 {
+		t.Fatalf("unexpected merged readings %v", m)
+	}
+	if r := m["requests"]; r.Value != 5 || r.Time != t1 {
+		t.Errorf("counters should be summed, got %+v", r)
+	}
+	if r := m["heap"]; r.Value != 20 {
+		t.Errorf("latest gauge should be kept, got %+v", r)
+	}
+	if r := m["time"]; !reflect.DeepEqual(r.Sample, []int64{1, 2, 3, 5}) {
+		t.Errorf("samples should be merged, got %+v", r)
+	}
+	if m["a"].Value != 1 || m["b"].Value != 2 {
+		t.Error("readings missing from merge")
+	}
+}
+
+func TestReadingsDiff(t *testing.T) {
+	a := Readings{
+		"heap":  {Name: "heap", Kind: KindGauge, Value: 20},
+		"procs": {Name: "procs", Kind: KindGauge, Value: 4},
+		"time":  {Name: "time", Kind: KindDistribution, Sample: []int64{1, 5}},
+		"gone":  {Name: "gone", Kind: KindGauge, Value: 1},
+	}
+	b := Readings{
+		"heap":  {Name: "heap", Kind: KindGauge, Value: 15},
+		"procs": {Name: "procs", Kind: KindGauge, Value: 4},
+		"time":  {Name: "time", Kind: KindDistribution, Sample: []int64{1, 5}},
+		"new":   {Name: "new", Kind: KindGauge, Value: 1},
+	}
+	d := a.Diff(b)
+	if len(d) != 2 {
+		t.Fatalf("unexpected diff %v", d)
+	}
+	if d["heap"].Value != -5 || d["new"].Value != 1 {
+		t.Errorf("unexpected diff %v", d)
+	}
+}
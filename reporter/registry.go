@@ -1,7 +1,12 @@
 package reporter
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/heroku/instruments"
 )
@@ -30,104 +35,746 @@ func Snapshot() map[string]interface{} {
 }
 
 // Registry is a registry of all instruments.
+//
+// Registries returned by Sub and WithTags are scoped views sharing their
+// instruments with their parent: names are prefixed and tags added on
+// registration, and only instruments within the scope are visible.
+// Registries returned by Filter further restrict the visible instruments,
+// typically to hand a subset of the instruments to a reporter.
 type Registry struct {
-	instruments map[string]interface{}
+	s      *store
+	prefix string
+	tags   Tags
+	filter Filter
+}
+
+type store struct {
+	entries     map[string]*entry
+	snapshot    map[string]interface{}
+	ttl         time.Duration
+	stop        chan struct{}
+	limit       int
+	limits      map[string]int
+	counts      map[string]int
+	internal    int
+	rejected    *instruments.Counter
+	policy      NamePolicy
+	subscribers []*subscriber
+	pending     []notification
+	delivering  bool
 	m           sync.RWMutex
 }
 
+type entry struct {
+	name     string
+	tags     Tags
+	md       Metadata
+	seen     time.Time
+	updates  uint64
+	internal bool
+	v        interface{}
+}
+
+// OverflowName is the name under which instruments are registered
+// once the registry reached its limit, followed by the instrument type.
+const OverflowName = "registry.overflow"
+
+// RejectedName is the name of the counter of registrations rejected
+// because the registry reached its limit, or by Instrument.
+const RejectedName = "registry.rejected"
+
+// reserved reports whether the name is reserved to the registry own instruments:
+// names under "registry", and overflow instruments under limited prefixes.
+func reserved(name string) bool {
+	return under(name, "registry") || strings.Contains(name+".", "."+OverflowName+".")
+}
+
+func (s *store) add(k string, e *entry) {
+	s.entries[k] = e
+	s.snapshot = nil
+	s.notify(Registered, e)
+	if e.internal {
+		s.internal++
+		return
+	}
+	for prefix := range s.limits {
+		if under(e.name, prefix) {
+			s.counts[prefix]++
+		}
+	}
+}
+
+func (s *store) remove(k string) {
+	e, present := s.entries[k]
+	if !present {
+		return
+	}
+	delete(s.entries, k)
+	s.snapshot = nil
+	s.notify(Removed, e)
+	if e.internal {
+		s.internal--
+		return
+	}
+	for prefix := range s.limits {
+		if under(e.name, prefix) {
+			s.counts[prefix]--
+		}
+	}
+}
+
+// full returns the limited prefix the name falls under which reached its limit,
+// or whether the whole registry reached its limit.
+func (s *store) full(name string) (string, bool) {
+	for prefix, limit := range s.limits {
+		if under(name, prefix) && s.counts[prefix] >= limit {
+			return prefix, true
+		}
+	}
+	return "", s.limit > 0 && len(s.entries)-s.internal >= s.limit
+}
+
+// reject counts a rejected registration.
+func (s *store) reject() {
+	if s.rejected == nil {
+		s.rejected = instruments.NewCounter()
+	}
+	s.rejected.Update(1)
+	if _, present := s.entries[RejectedName]; !present {
+		s.add(RejectedName, &entry{name: RejectedName, seen: time.Now(), internal: true, v: s.rejected})
+	}
+}
+
+// overflow counts the rejected registration and returns the overflow instrument
+// of the same type as v.
+func (s *store) overflow(prefix string, v interface{}) interface{} {
+	s.reject()
+
+	name := OverflowName + "." + typeName(v)
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	if e, present := s.entries[name]; present {
+		return e.v
+	}
+	s.add(name, &entry{name: name, seen: time.Now(), internal: true, v: v})
+	return v
+}
+
+func typeName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return "instrument"
+	}
+	return strings.ToLower(t.Name())
+}
+
+func under(name, prefix string) bool {
+	return name == prefix || strings.HasPrefix(name, prefix+".")
+}
+
+// updater is implemented by instruments counting their updates.
+type updater interface {
+	Updates() uint64
+}
+
+// expire unregisters instruments which haven't been updated within the ttl.
+func (s *store) expire(now time.Time) {
+	s.m.Lock()
+	defer s.unlock()
+	if s.ttl <= 0 {
+		return
+	}
+	for k, e := range s.entries {
+		u, ok := e.v.(updater)
+		if !ok {
+			continue
+		}
+		if n := u.Updates(); n != e.updates {
+			e.updates = n
+			e.seen = now
+		} else if now.Sub(e.seen) > s.ttl {
+			s.remove(k)
+		}
+	}
+}
+
+// Entry describes a registered instrument.
+type Entry struct {
+	// Name is the fully qualified name of the instrument.
+	Name string
+	// Tags are the tags of the registry scope, merged with the instrument tags if it is Tagged.
+	Tags Tags
+	// Metadata describes the instrument, defaulting to what can be inferred
+	// from the built-in instruments.
+	Metadata Metadata
+	// Instrument is the registered instrument.
+	Instrument interface{}
+}
+
+// Key returns the name qualified by the tags.
+func (e Entry) Key() string {
+	return e.Name + e.Tags.String()
+}
+
 // NewRegistry creates a new Register.
 func NewRegistry() *Registry {
 	return &Registry{
-		instruments: make(map[string]interface{}),
+		s: &store{
+			entries: make(map[string]*entry),
+		},
+	}
+}
+
+// Sub returns a view of the registry whose instruments names are prefixed with the given prefix,
+// followed by a dot.
+func (r *Registry) Sub(prefix string) *Registry {
+	if prefix == "" {
+		return r
+	}
+	r.s.m.RLock()
+	prefix = r.resolvePrefix(prefix)
+	r.s.m.RUnlock()
+	return &Registry{
+		s:      r.s,
+		prefix: prefix,
+		tags:   r.tags,
+		filter: r.filter,
+	}
+}
+
+// WithTags returns a view of the registry whose instruments are qualified by the given tags,
+// in addition to the registry own tags.
+func (r *Registry) WithTags(tags Tags) *Registry {
+	return &Registry{
+		s:      r.s,
+		prefix: r.prefix,
+		tags:   r.tags.Merge(tags),
+		filter: r.filter,
+	}
+}
+
+// Filter returns a view of the registry whose visible instruments are restricted
+// to the ones accepted by f, in addition to the registry own filter.
+// Registrations through the view are not filtered. Filters are called without
+// holding the registry lock, so they may use the registry.
+func (r *Registry) Filter(f Filter) *Registry {
+	if r.filter != nil {
+		f = All(r.filter, f)
+	}
+	return &Registry{
+		s:      r.s,
+		prefix: r.prefix,
+		tags:   r.tags,
+		filter: f,
 	}
 }
 
-// Get returns an instrument from the Registry.
+func (r *Registry) name(name string) string {
+	if r.prefix == "" {
+		return name
+	}
+	return r.prefix + "." + name
+}
+
+// resolve returns the fully qualified name and key of the named instrument,
+// as enforced by the name policy. It must be called with the store lock held.
+func (r *Registry) resolve(name string) (string, string, error) {
+	name = r.name(name)
+	if r.s.policy != nil {
+		var err error
+		if name, err = r.s.policy(name); err != nil {
+			return "", "", err
+		}
+	}
+	return name, name + r.tags.String(), nil
+}
+
+// resolvePrefix returns the fully qualified prefix as normalized by the name policy,
+// or unchanged if the policy rejects it. It must be called with the store lock held.
+func (r *Registry) resolvePrefix(prefix string) string {
+	prefix = r.name(prefix)
+	if r.s.policy != nil {
+		if p, err := r.s.policy(prefix); err == nil {
+			return p
+		}
+	}
+	return prefix
+}
+
+// SetNamePolicy sets the policy validating and normalizing instrument names
+// of the whole registry, including its parent and scoped views.
+// Registries accept any name by default, as does a nil policy.
+func (r *Registry) SetNamePolicy(p NamePolicy) {
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	r.s.policy = p
+}
+
+func (r *Registry) root() bool {
+	return r.prefix == "" && len(r.tags) == 0 && r.filter == nil
+}
+
+// contains reports whether the entry is within the registry prefix and tags.
+// The registry filter is applied separately, without holding the store lock.
+func (r *Registry) contains(e *entry) bool {
+	if r.prefix != "" && !under(e.name, r.prefix) {
+		return false
+	}
+	return e.tags.Contains(r.tags)
+}
+
+// accepts reports whether the registry filter accepts the entry.
+func (r *Registry) accepts(e Entry) bool {
+	return r.filter == nil || r.filter(e)
+}
+
+// visible returns the entries within the registry scope accepted by its filter,
+// along with their exported form. The filter is called once the store is unlocked.
+func (r *Registry) visible() map[*entry]Entry {
+	r.s.m.RLock()
+	entries := make(map[*entry]Entry)
+	for _, e := range r.s.entries {
+		if r.contains(e) {
+			entries[e] = e.export()
+		}
+	}
+	r.s.m.RUnlock()
+	if r.filter != nil {
+		for e, x := range entries {
+			if !r.filter(x) {
+				delete(entries, e)
+			}
+		}
+	}
+	return entries
+}
+
+// lookup returns the key and entry of the named instrument, along with
+// its exported form, if it is accepted by the registry filter.
+func (r *Registry) lookup(name string) (string, *entry, Entry, bool) {
+	r.s.m.RLock()
+	_, k, err := r.resolve(name)
+	e, present := r.s.entries[k]
+	var x Entry
+	if err == nil && present {
+		x = e.export()
+	}
+	r.s.m.RUnlock()
+	if err != nil || !present || !r.accepts(x) {
+		return "", nil, Entry{}, false
+	}
+	return k, e, x, true
+}
+
+func (e *entry) export() Entry {
+	tags := e.tags
+	if t, ok := e.v.(Tagged); ok {
+		tags = Tags(t.Tags()).Merge(e.tags)
+	}
+	return Entry{
+		Name:       e.name,
+		Tags:       tags,
+		Metadata:   e.md.merge(inferMetadata(e.v)),
+		Instrument: e.v,
+	}
+}
+
+// SetTTL enables the expiry of instruments which haven't been updated for the given duration,
+// typically a few reporting intervals. Expired instruments are unregistered by a background
+// goroutine checking the registry every ttl, so idle instruments are unregistered between one
+// and two ttl after their last update. Only instruments counting their updates, such as the
+// built-in instruments, expire. It applies to the whole registry, including its parent and
+// scoped views, and a zero duration disables expiry and stops the goroutine.
+func (r *Registry) SetTTL(d time.Duration) {
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	r.s.ttl = d
+	if r.s.stop != nil {
+		close(r.s.stop)
+		r.s.stop = nil
+	}
+	if d > 0 {
+		r.s.stop = make(chan struct{})
+		go r.s.expireEvery(d, r.s.stop)
+	}
+}
+
+// expireEvery expires instruments every d until stop is closed.
+func (s *store) expireEvery(d time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			s.expire(now)
+		}
+	}
+}
+
+// Get returns an instrument from the Registry, or nil if the registry filter rejects it.
 func (r *Registry) Get(name string) interface{} {
-	r.m.RLock()
-	defer r.m.RUnlock()
-	return r.instruments[name]
+	_, _, e, ok := r.lookup(name)
+	if !ok {
+		return nil
+	}
+	return e.Instrument
 }
 
 // Register registers a new instrument or return the existing one.
+// It returns nil if v is not an instrument, if the name policy rejects the name
+// or if the name is reserved to the registry, such as RejectedName.
 func (r *Registry) Register(name string, v interface{}) interface{} {
-	r.m.Lock()
-	defer r.m.Unlock()
+	i, _ := r.register(name, v, false)
+	return i
+}
+
+// Replace registers a new instrument, replacing the existing one.
+// It returns nil if v is not an instrument, if the name policy rejects the name
+// or if the name is reserved to the registry, such as RejectedName.
+func (r *Registry) Replace(name string, v interface{}) interface{} {
+	i, _ := r.register(name, v, true)
+	return i
+}
+
+func (r *Registry) register(name string, v interface{}, replace bool) (interface{}, error) {
 	switch v.(type) {
 	case instruments.Discrete, instruments.Sample:
-		i, present := r.instruments[name]
-		if present {
-			return i
+	default:
+		return nil, fmt.Errorf("%w: %q is a %T", ErrInvalidInstrument, name, v)
+	}
+	r.s.m.Lock()
+	defer r.s.unlock()
+	name, k, err := r.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if reserved(name) {
+		return nil, fmt.Errorf("%w: %q is reserved", ErrInvalidName, name)
+	}
+	if e, present := r.s.entries[k]; present {
+		if replace {
+			if inferMetadata(e.v) != inferMetadata(v) {
+				// The unit and kind described for the replaced instrument may not apply.
+				e.md = Metadata{Description: e.md.Description}
+			}
+			e.v = v
+			e.seen = time.Now()
+			e.updates = 0
+			r.s.snapshot = nil
+			r.s.notify(Replaced, e)
 		}
-		r.instruments[name] = v
-		return v
+		return e.v, nil
+	}
+	if prefix, full := r.s.full(name); full {
+		return r.s.overflow(prefix, v), nil
+	}
+	r.s.add(k, &entry{
+		name: name,
+		tags: r.tags,
+		seen: time.Now(),
+		v:    v,
+	})
+	return v, nil
+}
+
+// SetLimit caps the number of instruments in the whole registry, including its parent
+// and scoped views. Once reached, registrations return a shared instrument of the
+// same type registered as OverflowName followed by the type name, such as
+// "registry.overflow.counter", and are counted by the RejectedName counter.
+// A zero limit removes the cap.
+func (r *Registry) SetLimit(n int) {
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	r.s.limit = n
+}
+
+// SetPrefixLimit caps the number of instruments whose name starts with the given prefix,
+// relative to the registry scope. Once reached, registrations return a shared
+// instrument registered under the prefix, such as "prefix.registry.overflow.counter".
+// A zero limit removes the cap.
+func (r *Registry) SetPrefixLimit(prefix string, n int) {
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	prefix = r.resolvePrefix(prefix)
+	if n <= 0 {
+		delete(r.s.limits, prefix)
+		delete(r.s.counts, prefix)
+		return
+	}
+	if r.s.limits == nil {
+		r.s.limits = make(map[string]int)
+		r.s.counts = make(map[string]int)
+	}
+	if _, present := r.s.limits[prefix]; !present {
+		var count int
+		for _, e := range r.s.entries {
+			if !e.internal && under(e.name, prefix) {
+				count++
+			}
+		}
+		r.s.counts[prefix] = count
+	}
+	r.s.limits[prefix] = n
+}
+
+// Describe attaches metadata to the instrument registered under the given name.
+// Unset fields are inferred from the instrument type where possible.
+func (r *Registry) Describe(name string, md Metadata) {
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	_, k, err := r.resolve(name)
+	if err != nil {
+		return
+	}
+	if e, present := r.s.entries[k]; present {
+		e.md = md
 	}
-	return nil
 }
 
-// Unregister remove from the registry the instrument matching the given name.
+// Metadata returns the metadata of the instrument registered under the given name.
+func (r *Registry) Metadata(name string) Metadata {
+	r.s.m.RLock()
+	defer r.s.m.RUnlock()
+	_, k, err := r.resolve(name)
+	if err != nil {
+		return Metadata{}
+	}
+	if e, present := r.s.entries[k]; present {
+		return e.md.merge(inferMetadata(e.v))
+	}
+	return Metadata{}
+}
+
+// Unregister remove from the registry the instrument matching the given name,
+// unless the registry filter rejects it.
 func (r *Registry) Unregister(name string) {
-	r.m.Lock()
-	defer r.m.Unlock()
-	delete(r.instruments, name)
+	k, e, _, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	r.s.m.Lock()
+	defer r.s.unlock()
+	if r.s.entries[k] == e {
+		r.s.remove(k)
+	}
 }
 
 // Snapshot returns and reset all instruments.
 func (r *Registry) Snapshot() map[string]interface{} {
-	r.m.Lock()
-	defer r.m.Unlock()
-	instruments := r.instruments
-	r.instruments = make(map[string]interface{})
+	visible := r.visible()
+	r.s.m.Lock()
+	defer r.s.unlock()
+	instruments := make(map[string]interface{})
+	for k, e := range r.s.entries {
+		if _, ok := visible[e]; ok {
+			instruments[e.export().Key()] = e.v
+			r.s.remove(k)
+		}
+	}
 	return instruments
 }
 
-// Instruments returns all instruments.
+// Instruments returns all instruments, keyed by their Entry.Key.
+//
+// The returned map is a read-only copy which is safe to range over while
+// instruments are registered or unregistered concurrently. The copy is shared
+// between callers until the registry changes, so it must not be modified.
 func (r *Registry) Instruments() map[string]interface{} {
-	r.m.RLock()
-	defer r.m.RUnlock()
-	instruments := r.instruments
-	return instruments
+	if !r.root() {
+		instruments := make(map[string]interface{})
+		for _, e := range r.visible() {
+			instruments[e.Key()] = e.Instrument
+		}
+		return instruments
+	}
+
+	r.s.m.RLock()
+	snapshot := r.s.snapshot
+	r.s.m.RUnlock()
+	if snapshot != nil {
+		return snapshot
+	}
+
+	r.s.m.Lock()
+	defer r.s.m.Unlock()
+	if r.s.snapshot == nil {
+		r.s.snapshot = make(map[string]interface{}, len(r.s.entries))
+		for _, e := range r.s.entries {
+			r.s.snapshot[e.export().Key()] = e.v
+		}
+	}
+	return r.s.snapshot
+}
+
+// Entries returns all registered instruments along with their name and tags.
+func (r *Registry) Entries() []Entry {
+	visible := r.visible()
+	entries := make([]Entry, 0, len(visible))
+	for _, e := range visible {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Each calls f for each registered instrument.
+func (r *Registry) Each(f func(name string, v interface{})) {
+	for k, v := range r.Instruments() {
+		f(k, v)
+	}
 }
 
 // Size returns the numbers of instruments in the registry.
 func (r *Registry) Size() int {
-	r.m.RLock()
-	defer r.m.RUnlock()
-	return len(r.instruments)
+	if r.filter != nil {
+		return len(r.visible())
+	}
+	r.s.m.RLock()
+	defer r.s.m.RUnlock()
+	if r.root() {
+		return len(r.s.entries)
+	}
+	var n int
+	for _, e := range r.s.entries {
+		if r.contains(e) {
+			n++
+		}
+	}
+	return n
 }
 
+// ErrTypeMismatch is returned when the instrument registered under a name is not of the requested type.
+var ErrTypeMismatch = errors.New("reporter: instrument type mismatch")
+
+// ErrInvalidInstrument is returned when registering a value which is neither
+// a Discrete nor a Sample instrument.
+var ErrInvalidInstrument = errors.New("reporter: invalid instrument")
+
+// GetOrRegister returns the instrument registered under the given name,
+// registering the instrument created by f if there is none.
+// It returns an error wrapping ErrTypeMismatch if the registered instrument is not a T,
+// ErrInvalidInstrument if f doesn't create an instrument, or ErrInvalidName if the
+// registry name policy rejects the name or if it is reserved to the registry.
+func GetOrRegister[T any](r *Registry, name string, f func() T) (T, error) {
+	i := r.Get(name)
+	if i == nil {
+		var err error
+		if i, err = r.register(name, f(), false); err != nil {
+			var t T
+			return t, err
+		}
+	}
+	t, ok := i.(T)
+	if !ok {
+		return t, fmt.Errorf("%w: %q is a %T, not a %T", ErrTypeMismatch, name, i, t)
+	}
+	return t, nil
+}
+
+// NewRegisteredCounter returns the Counter registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Counter is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredCounter(name string) *instruments.Counter {
-	counter := instruments.NewCounter()
-	Register(name, counter)
+	counter, err := GetOrRegister(DefaultRegistry, name, instruments.NewCounter)
+	if err != nil {
+		return instruments.NewCounter()
+	}
 	return counter
 }
 
+// NewRegisteredRate returns the Rate registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Rate is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredRate(name string) *instruments.Rate {
-	rate := instruments.NewRate()
-	Register(name, rate)
+	rate, err := GetOrRegister(DefaultRegistry, name, instruments.NewRate)
+	if err != nil {
+		return instruments.NewRate()
+	}
 	return rate
 }
 
+// NewRegisteredDerive returns the Derive registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Derive is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredDerive(name string, value int64) *instruments.Derive {
-	derive := instruments.NewDerive(value)
-	Register(name, derive)
+	derive, err := GetOrRegister(DefaultRegistry, name, func() *instruments.Derive {
+		return instruments.NewDerive(value)
+	})
+	if err != nil {
+		return instruments.NewDerive(value)
+	}
 	return derive
 }
 
+// NewRegisteredReservoir returns the Reservoir registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Reservoir is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredReservoir(name string, size int64) *instruments.Reservoir {
-	reservoir := instruments.NewReservoir(size)
-	Register(name, reservoir)
+	reservoir, err := GetOrRegister(DefaultRegistry, name, func() *instruments.Reservoir {
+		return instruments.NewReservoir(size)
+	})
+	if err != nil {
+		return instruments.NewReservoir(size)
+	}
 	return reservoir
 }
 
+// NewRegisteredGauge returns the Gauge registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Gauge is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredGauge(name string, value int64) *instruments.Gauge {
-	gauge := instruments.NewGauge(value)
-	Register(name, gauge)
+	gauge, err := GetOrRegister(DefaultRegistry, name, func() *instruments.Gauge {
+		return instruments.NewGauge(value)
+	})
+	if err != nil {
+		return instruments.NewGauge(value)
+	}
 	return gauge
 }
 
+// NewRegisteredTimer returns the Timer registered in the default registry under the given name,
+// registering a new one if there is none. If another type of instrument is
+// registered under that name, the returned Timer is not registered.
+//
+// Deprecated: Use GetOrRegister, which reports name collisions.
 func NewRegisteredTimer(name string, size int64) *instruments.Timer {
-	timer := instruments.NewTimer(size)
-	Register(name, timer)
+	timer, err := GetOrRegister(DefaultRegistry, name, func() *instruments.Timer {
+		return instruments.NewTimer(size)
+	})
+	if err != nil {
+		return instruments.NewTimer(size)
+	}
 	return timer
 }
+
+// Instrument returns the instrument registered under the given name,
+// registering the instrument created by f if there is none, like GetOrRegister.
+// If GetOrRegister fails, such as when another type of instrument is registered
+// under the name, Instrument counts the rejected registration in the RejectedName
+// counter and returns the instrument created by f, unregistered.
+// It is meant for integrations which can't surface registration errors.
+func Instrument[T any](r *Registry, name string, f func() T) T {
+	t, err := GetOrRegister(r, name, f)
+	if err != nil {
+		r.s.m.Lock()
+		r.s.reject()
+		r.s.unlock()
+		return f()
+	}
+	return t
+}
@@ -1,8 +1,10 @@
 package reporter
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/heroku/instruments"
 )
@@ -47,6 +49,53 @@ func TestGetOrRegisterInstrument(t *testing.T) {
 	}
 }
 
+func TestGetOrRegister(t *testing.T) {
+	r := NewRegistry()
+	c, err := GetOrRegister(r, "foo", instruments.NewCounter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, err := GetOrRegister(r, "foo", instruments.NewCounter); err != nil || i != c {
+		t.Fatalf("registered instrument not returned: %v", err)
+	}
+	if _, err := GetOrRegister(r, "foo", instruments.NewRate); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected a type mismatch, got %v", err)
+	}
+	if _, err := GetOrRegister(r, "bar", func() int { return 0 }); !errors.Is(err, ErrInvalidInstrument) {
+		t.Fatalf("expected an invalid instrument, got %v", err)
+	}
+	if r.Size() != 1 {
+		t.Fatal("registry should only have one instrument registered")
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	r := NewRegistry()
+	c := Instrument(r, "foo", instruments.NewCounter)
+	if Instrument(r, "foo", instruments.NewCounter) != c {
+		t.Fatal("registered instrument not returned")
+	}
+	rate := Instrument(r, "foo", instruments.NewRate)
+	if rate == nil || r.Get("foo") != c {
+		t.Fatal("colliding instrument should be returned unregistered")
+	}
+	rejected, ok := r.Get(RejectedName).(*instruments.Counter)
+	if !ok || rejected.Snapshot() != 1 {
+		t.Fatal("collision should be counted as rejected")
+	}
+}
+
+func TestNewRegistered(t *testing.T) {
+	defer Unregister("foo")
+	c := NewRegisteredCounter("foo")
+	if NewRegisteredCounter("foo") != c {
+		t.Fatal("registered counter not returned")
+	}
+	if r := NewRegisteredRate("foo"); r == nil || Get("foo") != c {
+		t.Fatal("registered counter replaced")
+	}
+}
+
 func TestGetInstrument(t *testing.T) {
 	r := NewRegistry()
 	r.Register("foo", instruments.NewRate())
@@ -69,6 +118,251 @@ func TestSnapshotInstruments(t *testing.T) {
 	}
 }
 
+func TestInstrumentsConcurrentRegistration(t *testing.T) {
+	r := NewRegistry()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			name := fmt.Sprintf("foo.%d", i)
+			r.Register(name, instruments.NewRate())
+			r.Unregister(name)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			for range r.Instruments() {
+			}
+		}
+	}
+}
+
+func TestEach(t *testing.T) {
+	r := NewRegistry()
+	r.Register("foo", instruments.NewRate())
+	r.Register("bar", instruments.NewGauge(0))
+	seen := make(map[string]bool)
+	r.Each(func(name string, v interface{}) {
+		seen[name] = true
+		r.Unregister(name)
+	})
+	if !seen["foo"] || !seen["bar"] {
+		t.Errorf("instruments not iterated: %v", seen)
+	}
+	if r.Size() != 0 {
+		t.Error("instruments not unregistered")
+	}
+}
+
+func TestSubRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("query.time", instruments.NewTimer(-1))
+	db := r.Sub("db")
+	db.Register("query.time", instruments.NewTimer(-1))
+	db.Sub("pool").Register("size", instruments.NewGauge(0))
+
+	if r.Size() != 3 {
+		t.Fatalf("expected 3 instruments in root, got %d", r.Size())
+	}
+	if r.Get("db.query.time") == nil || db.Get("query.time") == nil {
+		t.Fatal("instrument not found")
+	}
+	if _, present := db.Instruments()["db.pool.size"]; !present || db.Size() != 2 {
+		t.Fatalf("unexpected scoped instruments %v", db.Instruments())
+	}
+
+	db.Unregister("query.time")
+	if r.Get("query.time") == nil || r.Get("db.query.time") != nil {
+		t.Fatal("unregister should only affect the scoped instrument")
+	}
+	if snapshot := db.Snapshot(); len(snapshot) != 1 || r.Size() != 1 {
+		t.Fatalf("snapshot should only reset the scoped instruments, got %v", snapshot)
+	}
+}
+
+func TestSubRegistryNamePolicy(t *testing.T) {
+	r := NewRegistry()
+	r.SetNamePolicy(NormalizeNames)
+	db := r.Sub("my db")
+	db.Register("q", instruments.NewCounter())
+	r.SetPrefixLimit("my db", 1)
+	db.Register("r", instruments.NewCounter())
+
+	if r.Get("my_db.q") == nil || db.Get("q") == nil {
+		t.Fatal("instrument not found")
+	}
+	if _, present := db.Instruments()["my_db.q"]; !present || db.Size() != 2 {
+		t.Fatalf("unexpected scoped instruments %v", db.Instruments())
+	}
+	if db.Get("r") != nil || db.Get("registry.overflow.counter") == nil {
+		t.Fatal("limit should apply to the normalized prefix")
+	}
+	if snapshot := db.Snapshot(); len(snapshot) != 2 || r.Get("my_db.q") != nil {
+		t.Fatalf("snapshot should reset the scoped instruments, got %v", snapshot)
+	}
+}
+
+type tagged struct {
+	*instruments.Gauge
+}
+
+func (tagged) Tags() map[string]string {
+	return map[string]string{"version": "1.0"}
+}
+
+func TestTaggedRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter())
+	a := r.WithTags(Tags{"route": "/a"})
+	b := r.WithTags(Tags{"route": "/b"})
+	a.Register("requests", instruments.NewCounter())
+	b.Register("requests", instruments.NewCounter())
+	r.Register("build", tagged{instruments.NewGauge(1)})
+
+	if r.Size() != 4 || a.Size() != 1 {
+		t.Fatalf("unexpected registry sizes %d and %d", r.Size(), a.Size())
+	}
+	if a.Get("requests") == b.Get("requests") {
+		t.Fatal("tagged instruments should be distinct")
+	}
+	if _, present := r.Instruments()["requests[route:/a]"]; !present {
+		t.Fatalf("tagged instrument not found in %v", r.Instruments())
+	}
+	if _, present := a.Instruments()["requests[route:/a]"]; !present {
+		t.Fatalf("tagged instrument not found in %v", a.Instruments())
+	}
+	if _, present := r.Instruments()["build[version:1.0]"]; !present {
+		t.Fatalf("instrument tags not used as key in %v", r.Instruments())
+	}
+	for _, e := range r.Entries() {
+		if e.Name == "build" && e.Key() != "build[version:1.0]" {
+			t.Fatalf("instrument tags not exported: %s", e.Key())
+		}
+	}
+	a.Unregister("requests")
+	if r.Size() != 3 || b.Get("requests") == nil {
+		t.Fatal("unregister should only affect the tagged instrument")
+	}
+}
+
+type constant int64
+
+func (c constant) Snapshot() int64 {
+	return int64(c)
+}
+
+func TestTTL(t *testing.T) {
+	r := NewRegistry()
+	r.SetTTL(time.Hour)
+	defer r.SetTTL(0)
+	c := instruments.NewCounter()
+	r.Register("counter", c)
+	r.Register("gauge", instruments.NewGauge(0))
+	r.Register("custom", constant(1))
+
+	now := time.Now()
+	c.Update(1)
+	r.s.expire(now.Add(30 * time.Minute))
+	r.s.expire(now.Add(90 * time.Minute))
+	if registered := r.Instruments(); len(registered) != 2 {
+		t.Fatalf("idle instruments not expired: %v", registered)
+	}
+	if r.Get("counter") == nil || r.Get("custom") == nil {
+		t.Fatal("active instrument expired")
+	}
+
+	r.s.expire(now.Add(3 * time.Hour))
+	if entries := r.Entries(); len(entries) != 1 {
+		t.Fatalf("idle instruments not expired: %v", entries)
+	}
+}
+
+func TestTTLTicker(t *testing.T) {
+	r := NewRegistry()
+	r.SetTTL(time.Millisecond)
+	defer r.SetTTL(0)
+	r.Register("counter", instruments.NewCounter())
+	for deadline := time.Now().Add(time.Second); r.Size() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("idle instrument not expired in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	r := NewRegistry()
+	r.SetLimit(2)
+	for i := 0; i < 5; i++ {
+		r.Register(fmt.Sprintf("foo.%d", i), instruments.NewCounter())
+	}
+	overflow, err := GetOrRegister(r, "foo.5", instruments.NewCounter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("registry.overflow.counter") != overflow {
+		t.Fatal("overflow instrument not returned")
+	}
+	if _, err := GetOrRegister(r, "foo.6", instruments.NewRate); err != nil || r.Get("registry.overflow.rate") == nil {
+		t.Fatalf("overflow instrument not registered by type: %v", err)
+	}
+	rejected, ok := r.Get(RejectedName).(*instruments.Counter)
+	if !ok {
+		t.Fatal("rejected counter not registered")
+	}
+	if s := rejected.Snapshot(); s != 5 {
+		t.Fatalf("expected 5 rejected registrations, got %d", s)
+	}
+
+	r.Unregister("foo.0")
+	r.Register("bar", instruments.NewCounter())
+	if r.Get("bar") == nil {
+		t.Fatal("instrument should be registered below the limit")
+	}
+}
+
+func TestPrefixLimit(t *testing.T) {
+	r := NewRegistry()
+	customers := r.Sub("customers")
+	customers.Register("a", instruments.NewCounter())
+	r.SetPrefixLimit("customers", 2)
+	customers.Register("b", instruments.NewCounter())
+	customers.Register("c", instruments.NewCounter())
+	r.Register("other", instruments.NewCounter())
+
+	if customers.Get("c") != nil || r.Get("other") == nil {
+		t.Fatal("limit should only apply to the prefix")
+	}
+	if customers.Get("registry.overflow.counter") == nil {
+		t.Fatal("overflow instrument not registered under the prefix")
+	}
+	customers.Unregister("a")
+	customers.Register("c", instruments.NewCounter())
+	if customers.Get("c") == nil {
+		t.Fatal("instrument should be registered below the prefix limit")
+	}
+}
+
+func TestReservedNames(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{RejectedName, "registry.overflow.counter", "customers.registry.overflow.counter"} {
+		if _, err := GetOrRegister(r, name, instruments.NewCounter); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%s: expected a reserved name, got %v", name, err)
+		}
+	}
+	if _, err := GetOrRegister(r.Sub("registry"), "rejected", instruments.NewCounter); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected a reserved name, got %v", err)
+	}
+	for _, name := range []string{"registry_size", "docker.registry.pulls"} {
+		if _, err := GetOrRegister(r, name, instruments.NewCounter); err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
 func BenchmarkInstruments(b *testing.B) {
 	r := NewRegistry()
 	for i := 0; i < 200000; i++ {
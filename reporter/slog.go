@@ -0,0 +1,31 @@
+package reporter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+// Slog logs metrics as structured attributes with the given logger every given duration.
+func Slog(logger *slog.Logger, r *Registry, d time.Duration) {
+	for range time.Tick(d) {
+		logger.LogAttrs(context.Background(), slog.LevelInfo, "metrics", slogAttrs(r)...)
+	}
+}
+
+// slogAttrs snapshots the registry instruments into attributes.
+func slogAttrs(r *Registry) []slog.Attr {
+	var attrs []slog.Attr
+	for _, e := range r.Entries() {
+		k := LogfmtName(e.Key())
+		switch i := e.Instrument.(type) {
+		case instruments.Discrete:
+			attrs = append(attrs, slog.Int64(k, i.Snapshot()))
+		case instruments.Sample:
+			attrs = append(attrs, slog.Int64(k, instruments.Quantile(i.Snapshot(), 0.95)))
+		}
+	}
+	return attrs
+}
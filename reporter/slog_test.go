@@ -0,0 +1,35 @@
+package reporter
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+func TestSlogAttrs(t *testing.T) {
+	r := NewRegistry()
+	r.Register("requests", instruments.NewCounter()).(*instruments.Counter).Update(2)
+	r.WithTags(Tags{"route": "/"}).Register("latency", instruments.NewReservoir(-1)).(*instruments.Reservoir).Update(10)
+
+	attrs := slogAttrs(r)
+	if len(attrs) != 2 {
+		t.Fatalf("expected 2 attributes, got %d", len(attrs))
+	}
+	expected := map[string]int64{
+		"latency[route:/]": 10,
+		"requests":         2,
+	}
+	for _, a := range attrs {
+		if v, ok := expected[a.Key]; !ok || a.Value.Int64() != v {
+			t.Errorf("unexpected attribute %s", a)
+		}
+	}
+}
+
+func ExampleSlog() {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	go Slog(logger, NewRegistry(), time.Minute)
+}
@@ -0,0 +1,22 @@
+package reporter
+
+import (
+	"context"
+
+	"github.com/heroku/instruments"
+)
+
+// StartSpan starts measuring a span recorded into the Timer registered under the given name,
+// and returns a context carrying the span. Its phases, started with instruments.StartPhase,
+// are recorded into Timers registered under the name followed by the phase name,
+// such as "checkout.db". Phases are registered like other instruments, so
+// r.SetPrefixLimit(name, n) bounds the number of phases timers.
+func StartSpan(ctx context.Context, r *Registry, name string) (context.Context, *instruments.Span) {
+	return Instrument(r, name, newTimer).StartPhases(ctx, func(phase string) *instruments.Timer {
+		return Instrument(r, name+"."+phase, newTimer)
+	})
+}
+
+func newTimer() *instruments.Timer {
+	return instruments.NewTimer(-1)
+}
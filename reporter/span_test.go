@@ -0,0 +1,44 @@
+package reporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/heroku/instruments"
+)
+
+func TestStartSpan(t *testing.T) {
+	r := NewRegistry()
+	r.SetPrefixLimit("checkout", 3)
+	ctx, span := StartSpan(context.Background(), r, "checkout")
+	dbCtx, db := instruments.StartPhase(ctx, "db")
+	_, query := instruments.StartPhase(dbCtx, "query")
+	query.Stop()
+	db.Stop()
+	_, render := instruments.StartPhase(ctx, "render")
+	render.Stop()
+	span.Stop()
+
+	for _, name := range []string{"checkout", "checkout.db", "checkout.db.query"} {
+		if tm, ok := r.Get(name).(*instruments.Timer); !ok || len(tm.Snapshot()) != 1 {
+			t.Errorf("%s: expected 1 recorded duration", name)
+		}
+	}
+	if r.Get("checkout.render") != nil {
+		t.Error("phases should be bounded by the prefix limit")
+	}
+	if tm, ok := r.Get("checkout.registry.overflow.timer").(*instruments.Timer); !ok || len(tm.Snapshot()) != 1 {
+		t.Error("phases over the limit should be recorded into the overflow timer")
+	}
+}
+
+func ExampleStartSpan() {
+	registry := NewRegistry()
+	registry.SetPrefixLimit("checkout", 10)
+	ctx, span := StartSpan(context.Background(), registry, "checkout")
+	defer span.Stop()
+
+	_, db := instruments.StartPhase(ctx, "db")
+	// query the database
+	db.Stop()
+}
@@ -0,0 +1,73 @@
+package reporter
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tags are key/value pairs qualifying an instrument.
+type Tags map[string]string
+
+// Tagged is implemented by instruments carrying their own tags.
+type Tagged interface {
+	Tags() map[string]string
+}
+
+// String returns the tags sorted by key and formatted as "[k1:v1,k2:v2]",
+// or an empty string if there are no tags. Backslashes, commas, colons and
+// brackets in keys and values are escaped with a backslash.
+func (t Tags) String() string {
+	if len(t) == 0 {
+		return ""
+	}
+	keys := t.Keys()
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		tagEscaper.WriteString(&b, k)
+		b.WriteByte(':')
+		tagEscaper.WriteString(&b, t[k])
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+var tagEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ":", `\:`, "[", `\[`, "]", `\]`)
+
+// Keys returns the sorted tag keys.
+func (t Tags) Keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Merge returns a new set of tags containing both t and o, o taking precedence.
+func (t Tags) Merge(o Tags) Tags {
+	if len(t) == 0 && len(o) == 0 {
+		return nil
+	}
+	m := make(Tags, len(t)+len(o))
+	for k, v := range t {
+		m[k] = v
+	}
+	for k, v := range o {
+		m[k] = v
+	}
+	return m
+}
+
+// Contains reports whether all of o tags are in t.
+func (t Tags) Contains(o Tags) bool {
+	for k, v := range o {
+		if w, present := t[k]; !present || w != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package reporter
+
+import "testing"
+
+var tagsTests = []struct {
+	tags Tags
+	s    string
+}{
+	{nil, ""},
+	{Tags{"route": "/"}, "[route:/]"},
+	{Tags{"status": "2xx", "method": "GET"}, "[method:GET,status:2xx]"},
+	{Tags{"a": "1,b:2"}, `[a:1\,b\:2]`},
+	{Tags{"a[0]": `c:\`}, `[a\[0\]:c\:\\]`},
+}
+
+func TestTagsString(t *testing.T) {
+	for i, tt := range tagsTests {
+		if s := tt.tags.String(); s != tt.s {
+			t.Errorf("%d: wants %q got %q", i, tt.s, s)
+		}
+	}
+}
+
+func TestTagsStringCollision(t *testing.T) {
+	a := Tags{"a": "1,b:2"}
+	b := Tags{"a": "1", "b": "2"}
+	if a.String() == b.String() {
+		t.Errorf("distinct tags share the same string %q", a.String())
+	}
+}
+
+func TestTagsMerge(t *testing.T) {
+	a := Tags{"a": "1", "b": "1"}
+	m := a.Merge(Tags{"b": "2"})
+	if m["a"] != "1" || m["b"] != "2" || a["b"] != "1" {
+		t.Errorf("unexpected merged tags %v", m)
+	}
+	if !m.Contains(Tags{"b": "2"}) || m.Contains(Tags{"b": "1"}) || m.Contains(Tags{"c": "1"}) {
+		t.Error("unexpected tags containment")
+	}
+}
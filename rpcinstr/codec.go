@@ -0,0 +1,271 @@
+// Package rpcinstr provides instrumentation for net/rpc servers and clients.
+//
+// Codecs record, per method:
+//
+// - time: Timer of calls durations.
+//
+// - calls: Counter of calls.
+//
+// - errors: Counter of failed calls.
+//
+// - request.size and response.size: Reservoirs of payload sizes in bytes.
+// Sizes of payloads read are approximate when calls are pipelined over a connection,
+// as codecs buffer their reads.
+//
+// Servers record calls to methods they can't find under the UnknownMethod tag.
+package rpcinstr
+
+import (
+	"io"
+	"net/rpc"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// UnknownMethod is the method tag of calls to methods servers can't find,
+// so that clients can't create instruments for arbitrary method names.
+const UnknownMethod = "unknown"
+
+// unresolved reports whether the server failed to find the method of a call,
+// as net/rpc replies with the method name sent by the client.
+// It is best-effort: it matches the error messages of net/rpc, which doesn't
+// expose the methods it serves, so calls to unknown methods are tagged by name
+// if a later Go release changes them.
+func unresolved(resp *rpc.Response) bool {
+	return strings.HasPrefix(resp.Error, "rpc: can't find ") ||
+		strings.HasPrefix(resp.Error, "rpc: service/method request ill-formed")
+}
+
+// countingConn counts the bytes read from and written to a connection.
+type countingConn struct {
+	io.ReadWriteCloser
+	read    int64
+	written int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	atomic.AddInt64(&c.read, int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	atomic.AddInt64(&c.written, int64(n))
+	return n, err
+}
+
+func (c *countingConn) bytesRead() int64 {
+	return atomic.LoadInt64(&c.read)
+}
+
+func (c *countingConn) bytesWritten() int64 {
+	return atomic.LoadInt64(&c.written)
+}
+
+type methodInstruments struct {
+	time         *instruments.Timer
+	calls        *instruments.Counter
+	errors       *instruments.Counter
+	requestSize  *instruments.Reservoir
+	responseSize *instruments.Reservoir
+}
+
+func (mi *methodInstruments) record(start time.Time, failed bool, requestSize, responseSize int64) {
+	mi.time.Since(start)
+	mi.calls.Update(1)
+	if failed {
+		mi.errors.Update(1)
+	}
+	mi.requestSize.Update(requestSize)
+	mi.responseSize.Update(responseSize)
+}
+
+// methods caches the instruments of each method.
+type methods struct {
+	r     *reporter.Registry
+	cache *reporter.Cache[*methodInstruments]
+}
+
+func newMethods(r *reporter.Registry) *methods {
+	return &methods{
+		r:     r,
+		cache: reporter.NewCache[*methodInstruments](r),
+	}
+}
+
+func (ms *methods) get(method string) *methodInstruments {
+	tags := reporter.Tags{"method": method}
+	return ms.cache.Get(tags.String(), func() *methodInstruments {
+		return newMethodInstruments(ms.r.WithTags(tags))
+	})
+}
+
+func (ms *methods) close() {
+	ms.cache.Close()
+}
+
+func newMethodInstruments(r *reporter.Registry) *methodInstruments {
+	newReservoir := func() *instruments.Reservoir {
+		return instruments.NewReservoir(-1)
+	}
+	mi := &methodInstruments{
+		time: reporter.Instrument(r, "time", func() *instruments.Timer {
+			return instruments.NewTimer(-1)
+		}),
+		calls:        reporter.Instrument(r, "calls", instruments.NewCounter),
+		errors:       reporter.Instrument(r, "errors", instruments.NewCounter),
+		requestSize:  reporter.Instrument(r, "request.size", newReservoir),
+		responseSize: reporter.Instrument(r, "response.size", newReservoir),
+	}
+	return mi
+}
+
+type call struct {
+	method      string
+	start       time.Time
+	requestSize int64
+}
+
+type serverCodec struct {
+	rpc.ServerCodec
+	conn    *countingConn
+	methods *methods
+	mark    int64
+	seq     uint64
+	method  string
+	start   time.Time
+	m       sync.Mutex
+	pending map[uint64]call
+}
+
+// NewServerCodec returns an rpc.ServerCodec created by newCodec over conn,
+// such as jsonrpc.NewServerCodec, recording the calls it serves into the registry.
+func NewServerCodec(conn io.ReadWriteCloser, newCodec func(io.ReadWriteCloser) rpc.ServerCodec, r *reporter.Registry) rpc.ServerCodec {
+	cc := &countingConn{ReadWriteCloser: conn}
+	return &serverCodec{
+		ServerCodec: newCodec(cc),
+		conn:        cc,
+		methods:     newMethods(r),
+		pending:     make(map[uint64]call),
+	}
+}
+
+func (c *serverCodec) ReadRequestHeader(req *rpc.Request) error {
+	c.mark = c.conn.bytesRead()
+	err := c.ServerCodec.ReadRequestHeader(req)
+	c.start = time.Now()
+	c.seq = req.Seq
+	c.method = req.ServiceMethod
+	return err
+}
+
+func (c *serverCodec) ReadRequestBody(body interface{}) error {
+	err := c.ServerCodec.ReadRequestBody(body)
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.pending[c.seq] = call{
+		method:      c.method,
+		start:       c.start,
+		requestSize: c.conn.bytesRead() - c.mark,
+	}
+	return err
+}
+
+func (c *serverCodec) WriteResponse(resp *rpc.Response, body interface{}) error {
+	written := c.conn.bytesWritten()
+	err := c.ServerCodec.WriteResponse(resp, body)
+	size := c.conn.bytesWritten() - written
+
+	c.m.Lock()
+	cl, present := c.pending[resp.Seq]
+	delete(c.pending, resp.Seq)
+	c.m.Unlock()
+	if !present {
+		cl = call{method: resp.ServiceMethod, start: time.Now()}
+	}
+	if unresolved(resp) {
+		cl.method = UnknownMethod
+	}
+	c.methods.get(cl.method).record(cl.start, resp.Error != "" || err != nil, cl.requestSize, size)
+	return err
+}
+
+// Close closes the codec and stops caching the instruments of its methods.
+func (c *serverCodec) Close() error {
+	c.methods.close()
+	return c.ServerCodec.Close()
+}
+
+type clientCodec struct {
+	rpc.ClientCodec
+	conn    *countingConn
+	methods *methods
+	mark    int64
+	resp    call
+	failed  bool
+	m       sync.Mutex
+	pending map[uint64]call
+}
+
+// NewClientCodec returns an rpc.ClientCodec created by newCodec over conn,
+// such as jsonrpc.NewClientCodec, recording the calls it sends into the registry.
+func NewClientCodec(conn io.ReadWriteCloser, newCodec func(io.ReadWriteCloser) rpc.ClientCodec, r *reporter.Registry) rpc.ClientCodec {
+	cc := &countingConn{ReadWriteCloser: conn}
+	return &clientCodec{
+		ClientCodec: newCodec(cc),
+		conn:        cc,
+		methods:     newMethods(r),
+		pending:     make(map[uint64]call),
+	}
+}
+
+func (c *clientCodec) WriteRequest(req *rpc.Request, body interface{}) error {
+	start := time.Now()
+	written := c.conn.bytesWritten()
+	err := c.ClientCodec.WriteRequest(req, body)
+	size := c.conn.bytesWritten() - written
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.pending[req.Seq] = call{
+		method:      req.ServiceMethod,
+		start:       start,
+		requestSize: size,
+	}
+	return err
+}
+
+func (c *clientCodec) ReadResponseHeader(resp *rpc.Response) error {
+	c.mark = c.conn.bytesRead()
+	err := c.ClientCodec.ReadResponseHeader(resp)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	cl, present := c.pending[resp.Seq]
+	delete(c.pending, resp.Seq)
+	if !present {
+		cl = call{method: resp.ServiceMethod, start: time.Now()}
+	}
+	c.resp = cl
+	c.failed = resp.Error != "" || err != nil
+	return err
+}
+
+func (c *clientCodec) ReadResponseBody(body interface{}) error {
+	err := c.ClientCodec.ReadResponseBody(body)
+	size := c.conn.bytesRead() - c.mark
+	c.methods.get(c.resp.method).record(c.resp.start, c.failed || err != nil, c.resp.requestSize, size)
+	return err
+}
+
+// Close closes the codec and stops caching the instruments of its methods.
+func (c *clientCodec) Close() error {
+	c.methods.close()
+	return c.ClientCodec.Close()
+}
@@ -0,0 +1,181 @@
+package rpcinstr
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"testing"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+type Args struct {
+	A, B int
+}
+
+type Arith int
+
+func (t *Arith) Multiply(args *Args, reply *int) error {
+	*reply = args.A * args.B
+	return nil
+}
+
+func (t *Arith) Divide(args *Args, reply *int) error {
+	if args.B == 0 {
+		return errors.New("divide by zero")
+	}
+	*reply = args.A / args.B
+	return nil
+}
+
+func snapshot(r *reporter.Registry, method, name string) int64 {
+	switch i := r.WithTags(reporter.Tags{"method": method}).Get(name).(type) {
+	case *instruments.Counter:
+		return i.Snapshot()
+	case *instruments.Timer:
+		return int64(len(i.Snapshot()))
+	case *instruments.Reservoir:
+		return instruments.Max(i.Snapshot())
+	}
+	return -1
+}
+
+func testCodecs(t *testing.T, server func(net.Conn, *reporter.Registry) rpc.ServerCodec, client func(net.Conn, *reporter.Registry) rpc.ClientCodec) {
+	r := reporter.NewRegistry()
+	s := rpc.NewServer()
+	if err := s.Register(new(Arith)); err != nil {
+		t.Fatal(err)
+	}
+	sc, cc := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		s.ServeCodec(server(sc, r.Sub("rpc.server")))
+		close(done)
+	}()
+	c := rpc.NewClientWithCodec(client(cc, r.Sub("rpc.client")))
+
+	var reply int
+	for i := 0; i < 2; i++ {
+		if err := c.Call("Arith.Multiply", &Args{7, 8}, &reply); err != nil || reply != 56 {
+			t.Fatalf("unexpected reply %d: %v", reply, err)
+		}
+	}
+	if err := c.Call("Arith.Divide", &Args{7, 0}, &reply); err == nil {
+		t.Fatal("expected a division by zero")
+	}
+	for _, method := range []string{"Arith.Add", "Unknown.Method", "ill-formed"} {
+		if err := c.Call(method, &Args{7, 8}, &reply); err == nil {
+			t.Fatalf("expected %s to fail", method)
+		}
+	}
+	c.Close()
+	<-done
+
+	for _, side := range []string{"rpc.server", "rpc.client"} {
+		sr := r.Sub(side)
+		if v := snapshot(sr, "Arith.Multiply", "calls"); v != 2 {
+			t.Errorf("%s: expected 2 calls, got %d", side, v)
+		}
+		if v := snapshot(sr, "Arith.Multiply", "time"); v != 2 {
+			t.Errorf("%s: expected 2 timed calls, got %d", side, v)
+		}
+		if v := snapshot(sr, "Arith.Multiply", "errors"); v != 0 {
+			t.Errorf("%s: expected no errors, got %d", side, v)
+		}
+		if v := snapshot(sr, "Arith.Divide", "errors"); v != 1 {
+			t.Errorf("%s: expected 1 error, got %d", side, v)
+		}
+		if v := snapshot(sr, "Arith.Multiply", "request.size"); v <= 0 {
+			t.Errorf("%s: expected a positive request size, got %d", side, v)
+		}
+		if v := snapshot(sr, "Arith.Multiply", "response.size"); v <= 0 {
+			t.Errorf("%s: expected a positive response size, got %d", side, v)
+		}
+	}
+	sr := r.Sub("rpc.server")
+	if v := snapshot(sr, UnknownMethod, "errors"); v != 3 {
+		t.Errorf("expected 3 unknown methods errors, got %d", v)
+	}
+	if sr.WithTags(reporter.Tags{"method": "Arith.Add"}).Get("calls") != nil {
+		t.Error("unknown methods should not be tagged by name")
+	}
+}
+
+func TestGobCodecs(t *testing.T) {
+	testCodecs(t, func(conn net.Conn, r *reporter.Registry) rpc.ServerCodec {
+		return NewServerCodec(conn, GobServerCodec, r)
+	}, func(conn net.Conn, r *reporter.Registry) rpc.ClientCodec {
+		return NewClientCodec(conn, GobClientCodec, r)
+	})
+}
+
+func TestJSONCodecs(t *testing.T) {
+	testCodecs(t, func(conn net.Conn, r *reporter.Registry) rpc.ServerCodec {
+		return NewServerCodec(conn, jsonrpc.NewServerCodec, r)
+	}, func(conn net.Conn, r *reporter.Registry) rpc.ClientCodec {
+		return NewClientCodec(conn, jsonrpc.NewClientCodec, r)
+	})
+}
+
+func TestGobCodecsCompatibility(t *testing.T) {
+	s := rpc.NewServer()
+	if err := s.Register(new(Arith)); err != nil {
+		t.Fatal(err)
+	}
+	sc, cc := net.Pipe()
+	go s.ServeConn(sc)
+	c := rpc.NewClientWithCodec(GobClientCodec(cc))
+	defer c.Close()
+	var reply int
+	if err := c.Call("Arith.Multiply", &Args{7, 8}, &reply); err != nil || reply != 56 {
+		t.Fatalf("unexpected reply %d: %v", reply, err)
+	}
+}
+
+func TestGobServerCodec(t *testing.T) {
+	s := rpc.NewServer()
+	if err := s.Register(new(Arith)); err != nil {
+		t.Fatal(err)
+	}
+	sc, cc := net.Pipe()
+	codec := GobServerCodec(sc)
+	done := make(chan struct{})
+	go func() {
+		s.ServeCodec(codec)
+		close(done)
+	}()
+	c := rpc.NewClient(cc)
+	var reply int
+	if err := c.Call("Arith.Multiply", &Args{7, 8}, &reply); err != nil || reply != 56 {
+		t.Fatalf("unexpected reply %d: %v", reply, err)
+	}
+	if err := c.Call("Arith.Divide", &Args{7, 0}, &reply); err == nil || err.Error() != "divide by zero" {
+		t.Fatalf("expected a division by zero, got %v", err)
+	}
+	c.Close()
+	<-done
+	if err := codec.Close(); err != nil {
+		t.Errorf("closing the codec twice should not fail, got %v", err)
+	}
+}
+
+var unresolvedTests = []struct {
+	err        string
+	unresolved bool
+}{
+	{"rpc: can't find service Unknown.Method", true},
+	{"rpc: can't find method Arith.Add", true},
+	{"rpc: service/method request ill-formed: ill-formed", true},
+	{"divide by zero", false},
+	{"", false},
+}
+
+func TestUnresolved(t *testing.T) {
+	for i, ut := range unresolvedTests {
+		if u := unresolved(&rpc.Response{Error: ut.err}); u != ut.unresolved {
+			t.Errorf("%d: wants %v got %v", i, ut.unresolved, u)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package runtime
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/heroku/instruments"
+)
+
+// start approximates the process start time with the initialization time of this package,
+// which runs before main but after the process started.
+var start = time.Now()
+
+// Build exposes build and environment information.
+// Its value is always 1, the information being carried by its tags,
+// which registries expose as it implements reporter.Tagged.
+type Build struct {
+	tags map[string]string
+}
+
+// NewBuild creates a new Build from the build information embedded in the binary.
+func NewBuild() *Build {
+	tags := map[string]string{
+		"go_version": runtime.Version(),
+		"goos":       runtime.GOOS,
+		"goarch":     runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		tags["path"] = bi.Main.Path
+		tags["version"] = bi.Main.Version
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				tags["revision"] = s.Value
+			case "vcs.modified":
+				tags["modified"] = s.Value
+			}
+		}
+	}
+	return &Build{
+		tags: tags,
+	}
+}
+
+// Tags returns the build information, such as the Go version or VCS revision.
+func (b *Build) Tags() map[string]string {
+	tags := make(map[string]string, len(b.tags))
+	for k, v := range b.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// Snapshot always returns 1.
+func (b *Build) Snapshot() int64 {
+	return 1
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (b *Build) Peek() int64 {
+	return 1
+}
+
+// Procs collects the maximum number of CPUs executing simultaneously.
+type Procs struct {
+	g *instruments.Gauge
+}
+
+// NewProcs creates a new Procs.
+func NewProcs() *Procs {
+	return &Procs{
+		g: instruments.NewGauge(int64(runtime.GOMAXPROCS(0))),
+	}
+}
+
+// Update updates the current GOMAXPROCS setting.
+func (p *Procs) Update() {
+	p.g.Update(int64(runtime.GOMAXPROCS(0)))
+}
+
+// Snapshot returns the current GOMAXPROCS setting.
+func (p *Procs) Snapshot() int64 {
+	return p.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (p *Procs) Peek() int64 {
+	return p.g.Peek()
+}
+
+// Start exposes the process start time, as of the initialization of this package.
+type Start struct{}
+
+// NewStart creates a new Start.
+func NewStart() *Start {
+	return &Start{}
+}
+
+// Tags returns the process start time formatted as RFC 3339.
+func (s *Start) Tags() map[string]string {
+	return map[string]string{
+		"start": start.UTC().Format(time.RFC3339),
+	}
+}
+
+// Snapshot returns the process start time as seconds since the Unix epoch.
+func (s *Start) Snapshot() int64 {
+	return start.Unix()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (s *Start) Peek() int64 {
+	return start.Unix()
+}
+
+// Uptime collects the time elapsed since the process started,
+// as of the initialization of this package.
+type Uptime struct {
+	g *instruments.Gauge
+}
+
+// NewUptime creates a new Uptime.
+func NewUptime() *Uptime {
+	return &Uptime{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the time elapsed since the process started.
+func (u *Uptime) Update() {
+	u.g.Update(int64(time.Since(start) / time.Second))
+}
+
+// Snapshot returns the time elapsed since the process started in seconds.
+func (u *Uptime) Snapshot() int64 {
+	return u.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (u *Uptime) Peek() int64 {
+	return u.g.Peek()
+}
@@ -0,0 +1,46 @@
+package runtime
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	b := NewBuild()
+	if s := b.Snapshot(); s != 1 {
+		t.Fatalf("expected 1, got %d", s)
+	}
+	tags := b.Tags()
+	if tags["go_version"] != runtime.Version() {
+		t.Fatalf("unexpected go version %q", tags["go_version"])
+	}
+	if tags["goos"] != runtime.GOOS || tags["goarch"] != runtime.GOARCH {
+		t.Fatalf("unexpected platform %s/%s", tags["goos"], tags["goarch"])
+	}
+	tags["goos"] = "plan9"
+	if b.Tags()["goos"] != runtime.GOOS {
+		t.Fatal("tags should not be shared")
+	}
+}
+
+func TestProcs(t *testing.T) {
+	p := NewProcs()
+	p.Update()
+	if s := p.Snapshot(); s != int64(runtime.GOMAXPROCS(0)) {
+		t.Fatalf("expected %d procs, got %d", runtime.GOMAXPROCS(0), s)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	u := NewUptime()
+	before := int64(time.Since(start) / time.Second)
+	u.Update()
+	after := int64(time.Since(start) / time.Second)
+	if s := u.Snapshot(); s < before || s > after {
+		t.Fatalf("expected an uptime between %d and %d, got %d", before, after, s)
+	}
+	if s := NewStart().Snapshot(); s > time.Now().Unix() {
+		t.Fatalf("start time %d in the future", s)
+	}
+}
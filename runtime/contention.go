@@ -0,0 +1,185 @@
+package runtime
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Mutex collects the time spent waiting on contended mutexes from the mutex profile.
+type Mutex struct {
+	c *contention
+}
+
+// NewMutex creates a new Mutex, enabling the mutex profile with the given sampling fraction.
+// If r is not nil, the contention delay of the n most contended call sites
+// over the last update are reported as gauges registered under prefix.
+func NewMutex(fraction int, r *reporter.Registry, prefix string, n int) *Mutex {
+	runtime.SetMutexProfileFraction(fraction)
+	return &Mutex{
+		c: newContention("mutex", runtime.MutexProfile, r, prefix, n),
+	}
+}
+
+// Update updates the mutex contention delay.
+func (m *Mutex) Update() {
+	m.c.update()
+}
+
+// Snapshot returns the mutex contention delay, in milliseconds per second.
+func (m *Mutex) Snapshot() int64 {
+	return m.c.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (m *Mutex) Peek() int64 {
+	return m.c.d.Peek()
+}
+
+// Block collects the time spent blocked on synchronization primitives from the block profile.
+type Block struct {
+	c *contention
+}
+
+// NewBlock creates a new Block, enabling the block profile with the given sampling rate.
+// If r is not nil, the blocking delay of the n most contended call sites
+// over the last update are reported as gauges registered under prefix.
+func NewBlock(rate int, r *reporter.Registry, prefix string, n int) *Block {
+	runtime.SetBlockProfileRate(rate)
+	return &Block{
+		c: newContention("block", runtime.BlockProfile, r, prefix, n),
+	}
+}
+
+// Update updates the blocking delay.
+func (b *Block) Update() {
+	b.c.update()
+}
+
+// Snapshot returns the blocking delay, in milliseconds per second.
+func (b *Block) Snapshot() int64 {
+	return b.c.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (b *Block) Peek() int64 {
+	return b.c.d.Peek()
+}
+
+type contention struct {
+	name    string
+	profile func([]runtime.BlockProfileRecord) (int, bool)
+	d       *instruments.Derive
+	sites   *groups
+	cycles  map[string]int64
+	records []runtime.BlockProfileRecord
+	m       sync.Mutex
+}
+
+func newContention(name string, profile func([]runtime.BlockProfileRecord) (int, bool), r *reporter.Registry, prefix string, n int) *contention {
+	c := &contention{
+		name:    name,
+		profile: profile,
+		d:       instruments.NewDerive(0),
+		cycles:  make(map[string]int64),
+	}
+	if r != nil {
+		c.sites = newGroups(r, prefix, n)
+	}
+	return c
+}
+
+func (c *contention) update() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	n, ok := c.profile(c.records)
+	for !ok {
+		c.records = make([]runtime.BlockProfileRecord, n+n/4+16)
+		n, ok = c.profile(c.records)
+	}
+
+	var total int64
+	sites := make(map[string]int64)
+	for _, r := range c.records[:n] {
+		total += r.Cycles
+		if c.sites != nil {
+			sites[callSite(r.Stack())] += r.Cycles
+		}
+	}
+	cps := cyclesPerSecond(c.name)
+	c.d.Update(toMilliseconds(total, cps))
+
+	if c.sites == nil {
+		return
+	}
+	delays := make(map[string]int64)
+	for site, cycles := range sites {
+		if delta := cycles - c.cycles[site]; delta > 0 {
+			delays[site] = toMilliseconds(delta, cps)
+		}
+		c.cycles[site] = cycles
+	}
+	c.sites.update(delays)
+}
+
+// callSite returns the first function of the stack outside of the runtime,
+// falling back to the top function.
+func callSite(stack []uintptr) string {
+	var top string
+	frames := runtime.CallersFrames(stack)
+	for {
+		f, more := frames.Next()
+		if top == "" {
+			top = f.Function
+		}
+		if f.Function != "" && !isRuntimeFunction(f.Function) {
+			return f.Function
+		}
+		if !more {
+			return top
+		}
+	}
+}
+
+func toMilliseconds(cycles int64, cps float64) int64 {
+	if cps <= 0 {
+		return 0
+	}
+	return instruments.Ceil(float64(cycles) / cps * 1e3)
+}
+
+var (
+	cps     float64
+	cpsOnce sync.Once
+)
+
+// cyclesPerSecond returns the CPU ticks per second used by the runtime profiles,
+// as reported in the legacy text format of the named profile.
+func cyclesPerSecond(name string) float64 {
+	cpsOnce.Do(func() {
+		p := pprof.Lookup(name)
+		if p == nil {
+			return
+		}
+		var buf bytes.Buffer
+		if err := p.WriteTo(&buf, 1); err != nil {
+			return
+		}
+		s := bufio.NewScanner(&buf)
+		for s.Scan() {
+			if v := strings.TrimPrefix(s.Text(), "cycles/second="); v != s.Text() {
+				cps, _ = strconv.ParseFloat(v, 64)
+				return
+			}
+		}
+	})
+	return cps
+}
@@ -0,0 +1,65 @@
+package runtime
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func contend(m *sync.Mutex) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Lock()
+			time.Sleep(5 * time.Millisecond)
+			m.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMutex(t *testing.T) {
+	r := reporter.NewRegistry()
+	m := NewMutex(1, r, "mutex", 5)
+	defer runtime.SetMutexProfileFraction(0)
+	m.Update()
+	m.Snapshot()
+
+	contend(new(sync.Mutex))
+	m.Update()
+	if s := m.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive contention delay, got %d", s)
+	}
+	i, ok := r.Get("mutex.github.com/heroku/instruments/runtime.contend.func1").(*instruments.Gauge)
+	if !ok {
+		t.Fatalf("call site not registered: %v", r.Instruments())
+	}
+	if s := i.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive call site delay, got %d", s)
+	}
+
+	// Call sites without new contention are unregistered
+	m.Update()
+	if size := r.Size(); size != 0 {
+		t.Fatalf("expected no call sites, got %d", size)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	b := NewBlock(1, nil, "", 0)
+	defer runtime.SetBlockProfileRate(0)
+	b.Update()
+	b.Snapshot()
+
+	contend(new(sync.Mutex))
+	b.Update()
+	if s := b.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive blocking delay, got %d", s)
+	}
+}
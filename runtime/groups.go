@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"sort"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// groups maintains the gauges of the n largest groups in a registry,
+// unregistering groups as they fall out of the top n.
+type groups struct {
+	r      *reporter.Registry
+	prefix string
+	n      int
+	gauges map[string]*instruments.Gauge
+	// skipped are the groups colliding with another type of instrument.
+	skipped map[string]bool
+}
+
+func newGroups(r *reporter.Registry, prefix string, n int) *groups {
+	return &groups{
+		r:       r,
+		prefix:  prefix,
+		n:       n,
+		gauges:  make(map[string]*instruments.Gauge),
+		skipped: make(map[string]bool),
+	}
+}
+
+// update registers or updates the gauges of the n largest groups.
+func (g *groups) update(values map[string]int64) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if values[names[i]] != values[names[j]] {
+			return values[names[i]] > values[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if g.n > 0 && len(names) > g.n {
+		names = names[:g.n]
+	}
+
+	top := make(map[string]bool, len(names))
+	for _, name := range names {
+		top[name] = true
+		if g.skipped[name] {
+			continue
+		}
+		gauge, present := g.gauges[name]
+		if !present {
+			i, err := reporter.GetOrRegister(g.r, g.prefix+"."+name, func() *instruments.Gauge {
+				return instruments.NewGauge(0)
+			})
+			if err != nil {
+				g.skipped[name] = true
+				continue
+			}
+			gauge = i
+			g.gauges[name] = gauge
+		}
+		gauge.Update(values[name])
+	}
+	for name := range g.gauges {
+		if !top[name] {
+			g.r.Unregister(g.prefix + "." + name)
+			delete(g.gauges, name)
+		}
+	}
+}
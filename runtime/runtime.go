@@ -1,13 +1,14 @@
 // Package runtime provides runtime instrumentations
-// around memory usage, goroutine and cgo calls.
+// around memory usage, garbage collection, goroutine and cgo calls.
 package runtime
 
 import (
 	"runtime"
+	"strings"
 	"sync"
-	"sync/atomic"
 
 	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
 )
 
 // Allocated collects the number of bytes allocated and still in use.
@@ -38,6 +39,11 @@ func (a *Allocated) Snapshot() int64 {
 	return a.g.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (a *Allocated) Peek() int64 {
+	return a.g.Peek()
+}
+
 // Heap collects the number of bytes allocated and still in use in the heap.
 type Heap struct {
 	g   *instruments.Gauge
@@ -66,6 +72,11 @@ func (ha *Heap) Snapshot() int64 {
 	return ha.g.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (ha *Heap) Peek() int64 {
+	return ha.g.Peek()
+}
+
 // Stack collects the number of bytes used now in the stack.
 type Stack struct {
 	g   *instruments.Gauge
@@ -94,9 +105,18 @@ func (s *Stack) Snapshot() int64 {
 	return s.g.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (s *Stack) Peek() int64 {
+	return s.g.Peek()
+}
+
 // Goroutine collects the number of existing goroutines.
 type Goroutine struct {
-	g *instruments.Gauge
+	g      *instruments.Gauge
+	by     GroupBy
+	groups *groups
+	buf    []byte
+	m      sync.Mutex
 }
 
 // NewGoroutine creats a new Goroutine.
@@ -106,9 +126,47 @@ func NewGoroutine() *Goroutine {
 	}
 }
 
+// GroupBy selects how goroutines are grouped.
+type GroupBy int
+
+const (
+	// ByFunction groups goroutines by the first function of their stack
+	// outside of the runtime, sync and internal packages.
+	ByFunction GroupBy = iota
+	// ByWaitReason groups goroutines by their status or wait reason.
+	ByWaitReason
+)
+
+// NewGoroutineGroups creates a new Goroutine which also samples all goroutines stacks on update,
+// reporting the number of goroutines of the n largest groups as gauges registered
+// in the given registry under prefix.
+func NewGoroutineGroups(r *reporter.Registry, prefix string, by GroupBy, n int) *Goroutine {
+	return &Goroutine{
+		g:      instruments.NewGauge(0),
+		by:     by,
+		groups: newGroups(r, prefix, n),
+	}
+}
+
 // Update udpates the number of existing goroutines.
 func (gr *Goroutine) Update() {
 	gr.g.Update(int64(runtime.NumGoroutine()))
+	if gr.groups == nil {
+		return
+	}
+
+	gr.m.Lock()
+	defer gr.m.Unlock()
+
+	if gr.buf == nil {
+		gr.buf = make([]byte, 64<<10)
+	}
+	n := runtime.Stack(gr.buf, true)
+	for n == len(gr.buf) {
+		gr.buf = make([]byte, 2*len(gr.buf))
+		n = runtime.Stack(gr.buf, true)
+	}
+	gr.groups.update(groupGoroutines(gr.buf[:n], gr.by))
 }
 
 // Snapshot returns the current number of existing goroutines
@@ -116,6 +174,83 @@ func (gr *Goroutine) Snapshot() int64 {
 	return gr.g.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (gr *Goroutine) Peek() int64 {
+	return gr.g.Peek()
+}
+
+// groupGoroutines counts goroutines of a stack dump by group.
+func groupGoroutines(dump []byte, by GroupBy) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, block := range strings.Split(string(dump), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) == 0 || !strings.HasPrefix(lines[0], "goroutine ") {
+			continue
+		}
+		var group string
+		switch by {
+		case ByWaitReason:
+			group = waitReason(lines[0])
+		default:
+			group = topFunction(lines[1:])
+		}
+		if group != "" {
+			counts[group]++
+		}
+	}
+	return counts
+}
+
+// waitReason extracts the wait reason from a goroutine header,
+// such as "goroutine 18 [chan receive, 2 minutes]:".
+func waitReason(header string) string {
+	i := strings.Index(header, "[")
+	j := strings.LastIndex(header, "]")
+	if i < 0 || j < i {
+		return ""
+	}
+	reason := header[i+1 : j]
+	if k := strings.Index(reason, ","); k >= 0 {
+		reason = reason[:k]
+	}
+	return strings.ReplaceAll(reason, " ", "_")
+}
+
+// topFunction returns the first function of a goroutine stack
+// which doesn't belong to the runtime, falling back to the top function.
+// Frames are function lines followed by indented file lines, and the stack
+// may end with "...additional frames elided..." and a "created by" trailer.
+func topFunction(lines []string) string {
+	var top string
+	for _, f := range lines {
+		if strings.HasPrefix(f, "created by ") {
+			break
+		}
+		if f == "" || strings.HasPrefix(f, "\t") || strings.HasPrefix(f, "...") {
+			continue
+		}
+		if k := strings.LastIndex(f, "("); k > 0 {
+			f = f[:k]
+		}
+		if top == "" {
+			top = f
+		}
+		if !isRuntimeFunction(f) {
+			return f
+		}
+	}
+	return top
+}
+
+func isRuntimeFunction(f string) bool {
+	for _, prefix := range []string{"runtime.", "sync.", "internal/"} {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // Cgo collects the number of cgo calls made by the current process.
 type Cgo struct {
 	g *instruments.Gauge
@@ -138,6 +273,11 @@ func (c *Cgo) Snapshot() int64 {
 	return c.g.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (c *Cgo) Peek() int64 {
+	return c.g.Peek()
+}
+
 // Frees collects the number of frees.
 type Frees struct {
 	d   *instruments.Derive
@@ -166,6 +306,11 @@ func (f *Frees) Snapshot() int64 {
 	return f.d.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (f *Frees) Peek() int64 {
+	return f.d.Peek()
+}
+
 // Lookups collects the number of pointer lookups.
 type Lookups struct {
 	d   *instruments.Derive
@@ -194,6 +339,11 @@ func (l *Lookups) Snapshot() int64 {
 	return l.d.Snapshot()
 }
 
+// Peek returns the same value as Snapshot without resetting it.
+func (l *Lookups) Peek() int64 {
+	return l.d.Peek()
+}
+
 // Mallocs collects the number of mallocs.
 type Mallocs struct {
 	d   *instruments.Derive
@@ -222,14 +372,274 @@ func (m *Mallocs) Snapshot() int64 {
 	return m.d.Snapshot()
 }
 
-// Pauses collects pauses times.
-type Pauses struct {
-	r   *instruments.Reservoir
-	n   uint32
+// Peek returns the same value as Snapshot without resetting it.
+func (m *Mallocs) Peek() int64 {
+	return m.d.Peek()
+}
+
+// HeapObjects collects the number of allocated heap objects.
+type HeapObjects struct {
+	g   *instruments.Gauge
 	mem runtime.MemStats
 	m   sync.Mutex
 }
 
+// NewHeapObjects creates a new HeapObjects.
+func NewHeapObjects() *HeapObjects {
+	return &HeapObjects{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the number of allocated heap objects.
+func (h *HeapObjects) Update() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	runtime.ReadMemStats(&h.mem)
+	h.g.Update(int64(h.mem.HeapObjects))
+}
+
+// Snapshot returns the current number of allocated heap objects.
+func (h *HeapObjects) Snapshot() int64 {
+	return h.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (h *HeapObjects) Peek() int64 {
+	return h.g.Peek()
+}
+
+// HeapIdle collects the number of bytes in idle heap spans.
+type HeapIdle struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewHeapIdle creates a new HeapIdle.
+func NewHeapIdle() *HeapIdle {
+	return &HeapIdle{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the number of bytes in idle heap spans.
+func (h *HeapIdle) Update() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	runtime.ReadMemStats(&h.mem)
+	h.g.Update(int64(h.mem.HeapIdle))
+}
+
+// Snapshot returns the current number of bytes in idle heap spans.
+func (h *HeapIdle) Snapshot() int64 {
+	return h.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (h *HeapIdle) Peek() int64 {
+	return h.g.Peek()
+}
+
+// HeapReleased collects the number of bytes of physical memory returned to the OS.
+type HeapReleased struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewHeapReleased creates a new HeapReleased.
+func NewHeapReleased() *HeapReleased {
+	return &HeapReleased{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the number of bytes of physical memory returned to the OS.
+func (h *HeapReleased) Update() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	runtime.ReadMemStats(&h.mem)
+	h.g.Update(int64(h.mem.HeapReleased))
+}
+
+// Snapshot returns the current number of bytes of physical memory returned to the OS.
+func (h *HeapReleased) Snapshot() int64 {
+	return h.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (h *HeapReleased) Peek() int64 {
+	return h.g.Peek()
+}
+
+// HeapSys collects the number of bytes of heap memory obtained from the OS.
+type HeapSys struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewHeapSys creates a new HeapSys.
+func NewHeapSys() *HeapSys {
+	return &HeapSys{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the number of bytes of heap memory obtained from the OS.
+func (h *HeapSys) Update() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	runtime.ReadMemStats(&h.mem)
+	h.g.Update(int64(h.mem.HeapSys))
+}
+
+// Snapshot returns the current number of bytes of heap memory obtained from the OS.
+func (h *HeapSys) Snapshot() int64 {
+	return h.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (h *HeapSys) Peek() int64 {
+	return h.g.Peek()
+}
+
+// Sys collects the total number of bytes of memory obtained from the OS.
+type Sys struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewSys creates a new Sys.
+func NewSys() *Sys {
+	return &Sys{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the total number of bytes of memory obtained from the OS.
+func (s *Sys) Update() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	runtime.ReadMemStats(&s.mem)
+	s.g.Update(int64(s.mem.Sys))
+}
+
+// Snapshot returns the current total number of bytes of memory obtained from the OS.
+func (s *Sys) Snapshot() int64 {
+	return s.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (s *Sys) Peek() int64 {
+	return s.g.Peek()
+}
+
+// TotalAlloc collects the cumulative number of bytes allocated for heap objects.
+type TotalAlloc struct {
+	d   *instruments.Derive
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewTotalAlloc creates a new TotalAlloc.
+func NewTotalAlloc() *TotalAlloc {
+	return &TotalAlloc{
+		d: instruments.NewDerive(0),
+	}
+}
+
+// Update updates the cumulative number of bytes allocated.
+func (t *TotalAlloc) Update() {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	runtime.ReadMemStats(&t.mem)
+	t.d.Update(int64(t.mem.TotalAlloc))
+}
+
+// Snapshot returns the number of bytes allocated per second.
+func (t *TotalAlloc) Snapshot() int64 {
+	return t.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (t *TotalAlloc) Peek() int64 {
+	return t.d.Peek()
+}
+
+// SizeClasses collects the number of mallocs per allocation size class.
+//
+// Each size class is tracked by its own Derive, which can be registered
+// individually:
+//
+//	sc := runtime.NewSizeClasses()
+//	for _, size := range sc.Sizes() {
+//		registry.Register(fmt.Sprintf("mallocs.%d", size), sc.Mallocs(size))
+//	}
+type SizeClasses struct {
+	sizes   []uint32
+	mallocs map[uint32]*instruments.Derive
+	mem     runtime.MemStats
+	m       sync.Mutex
+}
+
+// NewSizeClasses creates a new SizeClasses.
+func NewSizeClasses() *SizeClasses {
+	sc := &SizeClasses{
+		mallocs: make(map[uint32]*instruments.Derive),
+	}
+	runtime.ReadMemStats(&sc.mem)
+	for _, c := range sc.mem.BySize {
+		if _, present := sc.mallocs[c.Size]; present {
+			continue
+		}
+		sc.sizes = append(sc.sizes, c.Size)
+		sc.mallocs[c.Size] = instruments.NewDerive(int64(c.Mallocs))
+	}
+	return sc
+}
+
+// Sizes returns the maximum object size of each size class.
+func (sc *SizeClasses) Sizes() []uint32 {
+	return sc.sizes
+}
+
+// Mallocs returns the instrument tracking the mallocs of the given size class,
+// or nil if there is no such size class.
+func (sc *SizeClasses) Mallocs(size uint32) *instruments.Derive {
+	return sc.mallocs[size]
+}
+
+// Update updates the number of mallocs of every size class.
+func (sc *SizeClasses) Update() {
+	sc.m.Lock()
+	defer sc.m.Unlock()
+
+	runtime.ReadMemStats(&sc.mem)
+	for _, c := range sc.mem.BySize {
+		if d, present := sc.mallocs[c.Size]; present {
+			d.Update(int64(c.Mallocs))
+		}
+	}
+}
+
+// Pauses collects pauses times.
+type Pauses struct {
+	r    *instruments.Reservoir
+	n    uint32
+	last uint64
+	mem  runtime.MemStats
+	m    sync.Mutex
+}
+
 // NewPauses creates a new Pauses.
 func NewPauses(size int64) *Pauses {
 	return &Pauses{
@@ -237,33 +647,205 @@ func NewPauses(size int64) *Pauses {
 	}
 }
 
-// Update updates GC pauses times.
+// Update updates GC pauses times with the pauses that ended since the last update.
 func (p *Pauses) Update() {
 	p.m.Lock()
 	defer p.m.Unlock()
 
 	runtime.ReadMemStats(&p.mem)
-	numGC := atomic.SwapUint32(&p.n, p.mem.NumGC)
-	i := numGC % uint32(len(p.mem.PauseNs))
-	j := p.mem.NumGC % uint32(len(p.mem.PauseNs))
-	if p.mem.NumGC-numGC >= uint32(len(p.mem.PauseNs)) {
-		for i = 0; i < uint32(len(p.mem.PauseNs)); i++ {
-			p.r.Update(int64(p.mem.PauseNs[i]))
-		}
-	} else {
-		if i > j {
-			for ; i < uint32(len(p.mem.PauseNs)); i++ {
-				p.r.Update(int64(p.mem.PauseNs[i]))
-			}
-			i = 0
+	size := uint32(len(p.mem.PauseNs))
+	n := p.mem.NumGC - p.n
+	if n > size {
+		n = size
+	}
+	last := p.last
+	for k := uint32(0); k < n; k++ {
+		i := (p.mem.NumGC - 1 - k) % size
+		end := p.mem.PauseEnd[i]
+		if end <= p.last {
+			break
 		}
-		for ; i < j; i++ {
-			p.r.Update(int64(p.mem.PauseNs[i]))
+		if end > last {
+			last = end
 		}
+		p.r.Update(int64(p.mem.PauseNs[i]))
 	}
+	p.n = p.mem.NumGC
+	p.last = last
 }
 
 // Snapshot returns a sample of GC pauses times.
 func (p *Pauses) Snapshot() []int64 {
 	return p.r.Snapshot()
 }
+
+// Peek returns the same value as Snapshot without resetting it.
+func (p *Pauses) Peek() []int64 {
+	return p.r.Peek()
+}
+
+// NumGC collects the number of completed GC cycles.
+type NumGC struct {
+	d   *instruments.Derive
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewNumGC creates a new NumGC.
+func NewNumGC() *NumGC {
+	return &NumGC{
+		d: instruments.NewDerive(0),
+	}
+}
+
+// Update updates the number of completed GC cycles.
+func (n *NumGC) Update() {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	runtime.ReadMemStats(&n.mem)
+	n.d.Update(int64(n.mem.NumGC))
+}
+
+// Snapshot returns the number of GC cycles per second.
+func (n *NumGC) Snapshot() int64 {
+	return n.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (n *NumGC) Peek() int64 {
+	return n.d.Peek()
+}
+
+// ForcedGC collects the number of GC cycles forced by the application.
+type ForcedGC struct {
+	d   *instruments.Derive
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewForcedGC creates a new ForcedGC.
+func NewForcedGC() *ForcedGC {
+	return &ForcedGC{
+		d: instruments.NewDerive(0),
+	}
+}
+
+// Update updates the number of forced GC cycles.
+func (f *ForcedGC) Update() {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	runtime.ReadMemStats(&f.mem)
+	f.d.Update(int64(f.mem.NumForcedGC))
+}
+
+// Snapshot returns the number of forced GC cycles per second.
+func (f *ForcedGC) Snapshot() int64 {
+	return f.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (f *ForcedGC) Peek() int64 {
+	return f.d.Peek()
+}
+
+// GCCPUFraction collects the fraction of CPU time used by the GC,
+// expressed in hundredths of a percent.
+type GCCPUFraction struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewGCCPUFraction creates a new GCCPUFraction.
+func NewGCCPUFraction() *GCCPUFraction {
+	return &GCCPUFraction{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the fraction of CPU time used by the GC since the program started.
+func (f *GCCPUFraction) Update() {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	runtime.ReadMemStats(&f.mem)
+	f.g.Update(instruments.Ceil(f.mem.GCCPUFraction * 1e4))
+}
+
+// Snapshot returns the fraction of CPU time used by the GC in hundredths of a percent.
+func (f *GCCPUFraction) Snapshot() int64 {
+	return f.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (f *GCCPUFraction) Peek() int64 {
+	return f.g.Peek()
+}
+
+// NextGC collects the heap size goal of the next GC cycle.
+type NextGC struct {
+	g   *instruments.Gauge
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewNextGC creates a new NextGC.
+func NewNextGC() *NextGC {
+	return &NextGC{
+		g: instruments.NewGauge(0),
+	}
+}
+
+// Update updates the heap size goal of the next GC cycle.
+func (n *NextGC) Update() {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	runtime.ReadMemStats(&n.mem)
+	n.g.Update(int64(n.mem.NextGC))
+}
+
+// Snapshot returns the heap size goal of the next GC cycle in bytes.
+func (n *NextGC) Snapshot() int64 {
+	return n.g.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (n *NextGC) Peek() int64 {
+	return n.g.Peek()
+}
+
+// PauseTotal collects the cumulative time spent in GC pauses.
+type PauseTotal struct {
+	d   *instruments.Derive
+	mem runtime.MemStats
+	m   sync.Mutex
+}
+
+// NewPauseTotal creates a new PauseTotal.
+func NewPauseTotal() *PauseTotal {
+	return &PauseTotal{
+		d: instruments.NewDerive(0),
+	}
+}
+
+// Update updates the cumulative time spent in GC pauses.
+func (p *PauseTotal) Update() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	runtime.ReadMemStats(&p.mem)
+	p.d.Update(int64(p.mem.PauseTotalNs))
+}
+
+// Snapshot returns the time spent in GC pauses, in nanoseconds per second.
+func (p *PauseTotal) Snapshot() int64 {
+	return p.d.Snapshot()
+}
+
+// Peek returns the same value as Snapshot without resetting it.
+func (p *PauseTotal) Peek() int64 {
+	return p.d.Peek()
+}
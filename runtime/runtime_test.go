@@ -3,12 +3,105 @@ package runtime
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
 )
 
+func blockOn(c chan struct{}) {
+	<-c
+}
+
+func TestGoroutineGroups(t *testing.T) {
+	c := make(chan struct{})
+	defer close(c)
+	for i := 0; i < 10; i++ {
+		go blockOn(c)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	r := reporter.NewRegistry()
+	g := NewGoroutineGroups(r, "goroutines", ByFunction, 3)
+	g.Update()
+	if s := g.Snapshot(); s < 10 {
+		t.Fatalf("expected at least 10 goroutines, got %d", s)
+	}
+	if size := r.Size(); size > 3 {
+		t.Fatalf("expected at most 3 groups, got %d", size)
+	}
+	i, ok := r.Get("goroutines.github.com/heroku/instruments/runtime.blockOn").(*instruments.Gauge)
+	if !ok {
+		t.Fatal("group not registered")
+	}
+	if s := i.Snapshot(); s != 10 {
+		t.Fatalf("expected 10 goroutines in group, got %d", s)
+	}
+
+	w := NewGoroutineGroups(r, "waiting", ByWaitReason, 1)
+	w.Update()
+	if i, ok := r.Get("waiting.chan_receive").(*instruments.Gauge); !ok || i.Snapshot() < 10 {
+		t.Fatal("wait reason group not registered")
+	}
+}
+
+var waitReasonTests = []struct {
+	header string
+	reason string
+}{
+	{"goroutine 1 [running]:", "running"},
+	{"goroutine 18 [chan receive, 2 minutes]:", "chan_receive"},
+	{"goroutine 7 [IO wait, locked to thread]:", "IO_wait"},
+	{"goroutine 7", ""},
+}
+
+func TestWaitReason(t *testing.T) {
+	for i, wt := range waitReasonTests {
+		if reason := waitReason(wt.header); reason != wt.reason {
+			t.Errorf("%d: wants %q got %q", i, wt.reason, reason)
+		}
+	}
+}
+
+var topFunctionTests = []struct {
+	stack    string
+	function string
+}{
+	{"main.main()\n\t/app/main.go:10 +0x1d", "main.main"},
+	{"runtime.gopark(0x0?)\n\t/go/src/runtime/proc.go:398 +0xce\nmain.wait(...)\n\t/app/main.go:5", "main.wait"},
+	{"runtime.gopark(0x0?)\n\t/go/src/runtime/proc.go:398 +0xce\n...additional frames elided...\ncreated by main.main in goroutine 1\n\t/app/main.go:12 +0x25", "runtime.gopark"},
+	{"main.f0()\n\t/app/main.go:3\n...additional frames elided...\nmain.main()\n\t/app/main.go:10", "main.f0"},
+	{"runtime.gopark(0x0?)\n\t/go/src/runtime/proc.go:398\n...additional frames elided...\nmain.deep()\n\t/app/main.go:7\ncreated by main.main\n\t/app/main.go:12", "main.deep"},
+}
+
+func TestTopFunction(t *testing.T) {
+	for i, tt := range topFunctionTests {
+		if f := topFunction(strings.Split(tt.stack, "\n")); f != tt.function {
+			t.Errorf("%d: wants %q got %q", i, tt.function, f)
+		}
+	}
+}
+
+func TestGroupsCollision(t *testing.T) {
+	r := reporter.NewRegistry()
+	c := instruments.NewCounter()
+	r.Register("groups.a", c)
+	g := newGroups(r, "groups", 2)
+	g.update(map[string]int64{"a": 1, "b": 2})
+	g.update(map[string]int64{"a": 1, "b": 2})
+	if r.Get("groups.a") != c {
+		t.Error("colliding instrument should not be replaced")
+	}
+	if i, ok := r.Get("groups.b").(*instruments.Gauge); !ok || i.Snapshot() != 2 {
+		t.Error("group not registered")
+	}
+	if !g.skipped["a"] {
+		t.Error("colliding group should be skipped")
+	}
+}
+
 func TestPauses(t *testing.T) {
 	p := NewPauses(1024)
 
@@ -41,6 +134,82 @@ func TestPauses(t *testing.T) {
 	}
 }
 
+func TestPausesInterval(t *testing.T) {
+	p := NewPauses(1024)
+	p.Update()
+	p.Snapshot()
+
+	// Pauses already observed must not be collected again
+	p.n = 0
+	p.Update()
+	if count := len(p.Snapshot()); count != 0 {
+		t.Fatalf("captured %d gc runs, expected 0", count)
+	}
+
+	runtime.GC()
+	p.Update()
+	if count := len(p.Snapshot()); count != 1 {
+		t.Fatalf("captured %d gc runs, expected 1", count)
+	}
+}
+
+func TestForcedGC(t *testing.T) {
+	f := NewForcedGC()
+	f.Update()
+	f.Snapshot()
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	f.Update()
+	if s := f.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive forced gc rate, got %d", s)
+	}
+}
+
+func TestNextGC(t *testing.T) {
+	n := NewNextGC()
+	n.Update()
+	if s := n.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive heap goal, got %d", s)
+	}
+}
+
+func TestHeapObjects(t *testing.T) {
+	h := NewHeapObjects()
+	h.Update()
+	if s := h.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive number of heap objects, got %d", s)
+	}
+}
+
+var sink []byte
+
+func TestSizeClasses(t *testing.T) {
+	sc := NewSizeClasses()
+	if len(sc.Sizes()) == 0 {
+		t.Fatal("no size classes found")
+	}
+	var size uint32
+	for _, s := range sc.Sizes() {
+		if s >= 128 {
+			size = s
+			break
+		}
+	}
+	d := sc.Mallocs(size)
+	if d == nil {
+		t.Fatalf("size class %d not tracked", size)
+	}
+	d.Snapshot()
+	for i := 0; i < 1000; i++ {
+		sink = make([]byte, size)
+	}
+	time.Sleep(10 * time.Millisecond)
+	sc.Update()
+	if s := d.Snapshot(); s <= 0 {
+		t.Fatalf("expected a positive malloc rate for size class %d, got %d", size, s)
+	}
+}
+
 func ExamplePauses() {
 	pauses := NewPauses(512)
 	go func() {
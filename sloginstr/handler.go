@@ -0,0 +1,96 @@
+// Package sloginstr provides a log/slog handler counting log records.
+package sloginstr
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Options configures the tags of the records counters.
+type Options struct {
+	// Group tags records with the logger group, such as "request.http".
+	Group bool
+	// Source tags records with the function logging them.
+	Source bool
+}
+
+// counters caches the records counters shared by a handler and its derived handlers.
+type counters struct {
+	r     *reporter.Registry
+	opts  Options
+	cache *reporter.Cache[*instruments.Counter]
+}
+
+func (c *counters) get(tags reporter.Tags) *instruments.Counter {
+	return c.cache.Get(tags.String(), func() *instruments.Counter {
+		return reporter.Instrument(c.r.WithTags(tags), "records", instruments.NewCounter)
+	})
+}
+
+// Handler counts the records handled by a slog.Handler into a registry,
+// as a "records" Counter tagged with the record level.
+type Handler struct {
+	h     slog.Handler
+	c     *counters
+	group string
+}
+
+// NewHandler returns a Handler counting the records handled by h into the registry.
+func NewHandler(h slog.Handler, r *reporter.Registry, opts Options) *Handler {
+	return &Handler{
+		h: h,
+		c: &counters{
+			r:     r,
+			opts:  opts,
+			cache: reporter.NewCache[*instruments.Counter](r),
+		},
+	}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler, counting the record before handling it.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	tags := reporter.Tags{"level": strings.ToLower(r.Level.String())}
+	if h.c.opts.Group && h.group != "" {
+		tags["group"] = h.group
+	}
+	if h.c.opts.Source && r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if f.Function != "" {
+			tags["source"] = f.Function
+		}
+	}
+	h.c.get(tags).Update(1)
+	return h.h.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{
+		h:     h.h.WithAttrs(attrs),
+		c:     h.c,
+		group: h.group,
+	}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	group := name
+	if h.group != "" {
+		group = h.group + "." + name
+	}
+	return &Handler{
+		h:     h.h.WithGroup(name),
+		c:     h.c,
+		group: group,
+	}
+}
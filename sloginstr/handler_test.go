@@ -0,0 +1,70 @@
+package sloginstr
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func counts(r *reporter.Registry) map[string]int64 {
+	m := make(map[string]int64)
+	for _, e := range r.Entries() {
+		m[e.Key()] = e.Instrument.(*instruments.Counter).Snapshot()
+	}
+	return m
+}
+
+func TestHandler(t *testing.T) {
+	r := reporter.NewRegistry()
+	h := NewHandler(slog.NewTextHandler(io.Discard, nil), r, Options{})
+	logger := slog.New(h)
+	logger.Info("hello")
+	logger.Info("world")
+	logger.Error("failed")
+	logger.Debug("ignored")
+	logger.WithGroup("request").With("id", 1).Warn("slow")
+
+	expected := map[string]int64{
+		"records[level:info]":  2,
+		"records[level:error]": 1,
+		"records[level:warn]":  1,
+	}
+	got := counts(r)
+	if len(got) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("expected %s to be %d, got %d", k, v, got[k])
+		}
+	}
+}
+
+func TestHandlerOptions(t *testing.T) {
+	r := reporter.NewRegistry()
+	h := NewHandler(slog.NewTextHandler(io.Discard, nil), r, Options{Group: true, Source: true})
+	slog.New(h).WithGroup("request").WithGroup("http").Info("hello")
+
+	entries := r.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 counter, got %d", len(entries))
+	}
+	tags := entries[0].Tags
+	if tags["group"] != "request.http" {
+		t.Errorf("expected request.http group, got %q", tags["group"])
+	}
+	if !strings.HasSuffix(tags["source"], "TestHandlerOptions") {
+		t.Errorf("expected TestHandlerOptions source, got %q", tags["source"])
+	}
+}
+
+func ExampleNewHandler() {
+	registry := reporter.NewRegistry()
+	h := NewHandler(slog.NewJSONHandler(os.Stderr, nil), registry, Options{Group: true})
+	slog.SetDefault(slog.New(h))
+}
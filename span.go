@@ -0,0 +1,82 @@
+package instruments
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type spanKey struct{}
+
+// Span is a duration measurement started into a context.
+type Span struct {
+	timer   *Timer
+	start   time.Time
+	name    string
+	phase   func(name string) *Timer
+	stopped uint32
+}
+
+// Start starts measuring a span recorded into the timer,
+// and returns a context carrying the span. Phases of the span aren't recorded.
+func (t *Timer) Start(ctx context.Context) (context.Context, *Span) {
+	return t.StartPhases(ctx, nil)
+}
+
+// StartPhases starts measuring a span recorded into the timer, whose phases are
+// recorded into the timers returned by phase, and returns a context carrying the span.
+// Nested phases names are joined with a dot, such as "db.query".
+// The phase function typically registers the timers, bounding the number of phases,
+// and may return nil for phases which shouldn't be recorded.
+func (t *Timer) StartPhases(ctx context.Context, phase func(name string) *Timer) (context.Context, *Span) {
+	s := &Span{
+		timer: t,
+		start: time.Now(),
+		phase: phase,
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// StartPhase starts measuring a phase of the span carried by ctx, recorded into
+// the timer the span returns for the phase, and returns a context carrying the phase span.
+// Phases can be nested. Without a span recording phases in ctx, the phase isn't
+// recorded and the returned span is nil.
+func StartPhase(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil || parent.phase == nil {
+		return ctx, nil
+	}
+	if parent.name != "" {
+		name = parent.name + "." + name
+	}
+	t := parent.phase(name)
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{
+		timer: t,
+		start: time.Now(),
+		name:  name,
+		phase: parent.phase,
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Stop records the span duration into its timer and returns it.
+// Only the first call records the duration. Stop is a no-op on a nil span.
+func (s *Span) Stop() time.Duration {
+	if s == nil {
+		return 0
+	}
+	d := time.Since(s.start)
+	if atomic.CompareAndSwapUint32(&s.stopped, 0, 1) {
+		s.timer.Update(d)
+	}
+	return d
+}
@@ -0,0 +1,128 @@
+package instruments
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// phases returns timers by phase name, created on first use.
+type phases struct {
+	m      sync.Mutex
+	timers map[string]*Timer
+}
+
+func (p *phases) get(name string) *Timer {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.timers == nil {
+		p.timers = make(map[string]*Timer)
+	}
+	t, ok := p.timers[name]
+	if !ok {
+		t = NewTimer(-1)
+		p.timers[name] = t
+	}
+	return t
+}
+
+func TestSpan(t *testing.T) {
+	tm := NewTimer(-1)
+	var p phases
+	ctx, span := tm.StartPhases(context.Background(), p.get)
+	if SpanFromContext(ctx) != span {
+		t.Fatal("context should carry the span")
+	}
+
+	dbCtx, db := StartPhase(ctx, "db")
+	_, query := StartPhase(dbCtx, "query")
+	time.Sleep(5 * time.Millisecond)
+	query.Stop()
+	db.Stop()
+	_, render := StartPhase(ctx, "render")
+	render.Stop()
+	span.Stop()
+	span.Stop()
+
+	if s := tm.Snapshot(); len(s) != 1 || s[0] < 5 {
+		t.Errorf("expected 1 duration of at least 5ms, got %v", s)
+	}
+	if len(p.timers) != 3 {
+		t.Fatalf("expected 3 phases, got %v", p.timers)
+	}
+	if s := p.timers["db"].Snapshot(); len(s) != 1 || s[0] < 5 {
+		t.Errorf("expected 1 db duration of at least 5ms, got %v", s)
+	}
+	if s := p.timers["db.query"].Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 nested query duration, got %v", s)
+	}
+	if s := p.timers["render"].Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 render duration, got %v", s)
+	}
+}
+
+func TestSpanSkippedPhase(t *testing.T) {
+	tm := NewTimer(-1)
+	ctx, span := tm.StartPhases(context.Background(), func(name string) *Timer {
+		return nil
+	})
+	defer span.Stop()
+	phaseCtx, phase := StartPhase(ctx, "db")
+	if phase != nil || SpanFromContext(phaseCtx) != span {
+		t.Error("skipped phases should not be recorded")
+	}
+	phase.Stop()
+}
+
+func TestSpanConcurrent(t *testing.T) {
+	tm := NewTimer(-1)
+	var p phases
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ctx, span := tm.StartPhases(context.Background(), p.get)
+			_, phase := StartPhase(ctx, "db")
+			phase.Stop()
+			span.Stop()
+		}()
+		go func() {
+			defer wg.Done()
+			tm.Snapshot()
+			p.get("db").Snapshot()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStartPhaseWithoutPhases(t *testing.T) {
+	ctx, span := StartPhase(context.Background(), "db")
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Error("phases without span should not be recorded")
+	}
+	span.Stop()
+
+	ctx, parent := NewTimer(-1).Start(context.Background())
+	if _, span := StartPhase(ctx, "db"); span != nil {
+		t.Error("phases of spans started without phases should not be recorded")
+	}
+	parent.Stop()
+}
+
+func ExampleTimer_StartPhases() {
+	timer := NewTimer(-1)
+	db := NewTimer(-1)
+	ctx, span := timer.StartPhases(context.Background(), func(name string) *Timer {
+		if name == "db" {
+			return db
+		}
+		return nil
+	})
+	defer span.Stop()
+
+	_, phase := StartPhase(ctx, "db")
+	// query the database
+	phase.Stop()
+}
@@ -0,0 +1,559 @@
+// Package sqlinstr provides instrumentation for database/sql drivers.
+package sqlinstr
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Driver wraps a driver.Driver, recording the database calls into a registry:
+//
+// - query.time and exec.time: Timers of queries and statements executions.
+//
+// - query.errors and exec.errors: Counters of failed queries and statements executions.
+//
+// - tx.time: Timer of transactions durations, from begin to commit or rollback.
+//
+// - tx.errors: Counter of failed transactions begins, commits and rollbacks.
+//
+// - rows: Counter of rows returned by queries.
+type Driver struct {
+	driver.Driver
+	i *driverInstruments
+}
+
+type driverInstruments struct {
+	queryTime   *instruments.Timer
+	queryErrors *instruments.Counter
+	execTime    *instruments.Timer
+	execErrors  *instruments.Counter
+	txTime      *instruments.Timer
+	txErrors    *instruments.Counter
+	rows        *instruments.Counter
+}
+
+func newDriverInstruments(r *reporter.Registry) *driverInstruments {
+	newTimer := func() *instruments.Timer {
+		return instruments.NewTimer(-1)
+	}
+	return &driverInstruments{
+		queryTime:   reporter.Instrument(r, "query.time", newTimer),
+		queryErrors: reporter.Instrument(r, "query.errors", instruments.NewCounter),
+		execTime:    reporter.Instrument(r, "exec.time", newTimer),
+		execErrors:  reporter.Instrument(r, "exec.errors", instruments.NewCounter),
+		txTime:      reporter.Instrument(r, "tx.time", newTimer),
+		txErrors:    reporter.Instrument(r, "tx.errors", instruments.NewCounter),
+		rows:        reporter.Instrument(r, "rows", instruments.NewCounter),
+	}
+}
+
+// Wrap returns a Driver recording the calls made through d into the registry,
+// to be registered with sql.Register.
+func Wrap(d driver.Driver, r *reporter.Registry) *Driver {
+	return &Driver{
+		Driver: d,
+		i:      newDriverInstruments(r),
+	}
+}
+
+// Open implements driver.Driver.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapConn(c, d.i), nil
+}
+
+// OpenConnector implements driver.DriverContext, returning the connector of
+// the wrapped driver if it implements driver.DriverContext.
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	dc, ok := d.Driver.(driver.DriverContext)
+	if !ok {
+		return &dsnConnector{name: name, d: d}, nil
+	}
+	c, err := dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrapConnector(c, d), nil
+}
+
+// WrapConnector returns a driver.Connector recording the calls made through c into the registry,
+// to be opened with sql.OpenDB.
+func WrapConnector(c driver.Connector, r *reporter.Registry) driver.Connector {
+	return wrapConnector(c, Wrap(c.Driver(), r))
+}
+
+// wrapConnector returns a connector which is an io.Closer only if c is one.
+func wrapConnector(c driver.Connector, d *Driver) driver.Connector {
+	cn := &connector{Connector: c, d: d}
+	if cl, ok := c.(io.Closer); ok {
+		return struct {
+			*connector
+			io.Closer
+		}{cn, cl}
+	}
+	return cn
+}
+
+type connector struct {
+	driver.Connector
+	d *Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return wrapConn(cn, c.d.i), nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.d
+}
+
+// dsnConnector opens connections with a driver which doesn't implement driver.DriverContext,
+// like database/sql does.
+type dsnConnector struct {
+	name string
+	d    *Driver
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.d.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.d
+}
+
+// record records a call duration and failure, ignoring driver.ErrSkip.
+func record(t *instruments.Timer, c *instruments.Counter, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	t.Since(start)
+	if err != nil {
+		c.Update(1)
+	}
+}
+
+type conn struct {
+	driver.Conn
+	i *driverInstruments
+}
+
+// wrapConn returns an instrumented connection which only implements the optional
+// driver.Pinger, driver.SessionResetter, driver.Validator and driver.NamedValueChecker
+// interfaces implemented by c, as database/sql behaves differently when they are missing.
+func wrapConn(c driver.Conn, i *driverInstruments) driver.Conn {
+	cn := &conn{Conn: c, i: i}
+	p, _ := c.(driver.Pinger)
+	sr, _ := c.(driver.SessionResetter)
+	v, _ := c.(driver.Validator)
+	nc, _ := c.(driver.NamedValueChecker)
+	var optional int
+	if p != nil {
+		optional |= 1
+	}
+	if sr != nil {
+		optional |= 2
+	}
+	if v != nil {
+		optional |= 4
+	}
+	if nc != nil {
+		optional |= 8
+	}
+	switch optional {
+	case 1:
+		return struct {
+			*conn
+			driver.Pinger
+		}{cn, p}
+	case 2:
+		return struct {
+			*conn
+			driver.SessionResetter
+		}{cn, sr}
+	case 3:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.SessionResetter
+		}{cn, p, sr}
+	case 4:
+		return struct {
+			*conn
+			driver.Validator
+		}{cn, v}
+	case 5:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.Validator
+		}{cn, p, v}
+	case 6:
+		return struct {
+			*conn
+			driver.SessionResetter
+			driver.Validator
+		}{cn, sr, v}
+	case 7:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.SessionResetter
+			driver.Validator
+		}{cn, p, sr, v}
+	case 8:
+		return struct {
+			*conn
+			driver.NamedValueChecker
+		}{cn, nc}
+	case 9:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.NamedValueChecker
+		}{cn, p, nc}
+	case 10:
+		return struct {
+			*conn
+			driver.SessionResetter
+			driver.NamedValueChecker
+		}{cn, sr, nc}
+	case 11:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.SessionResetter
+			driver.NamedValueChecker
+		}{cn, p, sr, nc}
+	case 12:
+		return struct {
+			*conn
+			driver.Validator
+			driver.NamedValueChecker
+		}{cn, v, nc}
+	case 13:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.Validator
+			driver.NamedValueChecker
+		}{cn, p, v, nc}
+	case 14:
+		return struct {
+			*conn
+			driver.SessionResetter
+			driver.Validator
+			driver.NamedValueChecker
+		}{cn, sr, v, nc}
+	case 15:
+		return struct {
+			*conn
+			driver.Pinger
+			driver.SessionResetter
+			driver.Validator
+			driver.NamedValueChecker
+		}{cn, p, sr, v, nc}
+	}
+	return cn
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return wrapStmt(s, c.i), nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	pc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	s, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return wrapStmt(s, c.i), nil
+}
+
+// ExecContext executes the query with the driver.ExecerContext or the legacy driver.Execer
+// implemented by the connection, or returns driver.ErrSkip to execute a prepared statement.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var run func() (driver.Result, error)
+	switch ec := c.Conn.(type) {
+	case driver.ExecerContext:
+		run = func() (driver.Result, error) {
+			return ec.ExecContext(ctx, query, args)
+		}
+	case driver.Execer: //nolint:staticcheck
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		run = func() (driver.Result, error) {
+			return ec.Exec(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := run()
+	record(c.i.execTime, c.i.execErrors, start, err)
+	return res, err
+}
+
+// QueryContext runs the query with the driver.QueryerContext or the legacy driver.Queryer
+// implemented by the connection, or returns driver.ErrSkip to query a prepared statement.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var run func() (driver.Rows, error)
+	switch qc := c.Conn.(type) {
+	case driver.QueryerContext:
+		run = func() (driver.Rows, error) {
+			return qc.QueryContext(ctx, query, args)
+		}
+	case driver.Queryer: //nolint:staticcheck
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		run = func() (driver.Rows, error) {
+			return qc.Query(query, values)
+		}
+	default:
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rs, err := run()
+	record(c.i.queryTime, c.i.queryErrors, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{Rows: rs, i: c.i}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	t, err := c.Conn.Begin() //nolint:staticcheck
+	if err != nil {
+		c.i.txErrors.Update(1)
+		return nil, err
+	}
+	return &tx{Tx: t, i: c.i, start: time.Now()}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	bc, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		if opts.ReadOnly || opts.Isolation != 0 {
+			return nil, errors.New("sqlinstr: driver does not support transaction options")
+		}
+		return c.Begin()
+	}
+	t, err := bc.BeginTx(ctx, opts)
+	if err != nil {
+		c.i.txErrors.Update(1)
+		return nil, err
+	}
+	return &tx{Tx: t, i: c.i, start: time.Now()}, nil
+}
+
+type stmt struct {
+	driver.Stmt
+	i *driverInstruments
+}
+
+// wrapStmt returns an instrumented statement which only implements the optional
+// driver.NamedValueChecker and driver.ColumnConverter interfaces implemented by s,
+// so that database/sql falls back to the connection checker and default converter otherwise.
+func wrapStmt(s driver.Stmt, i *driverInstruments) driver.Stmt {
+	st := &stmt{Stmt: s, i: i}
+	nc, _ := s.(driver.NamedValueChecker)
+	cc, _ := s.(driver.ColumnConverter) //nolint:staticcheck
+	switch {
+	case nc != nil && cc != nil:
+		return struct {
+			*stmt
+			driver.NamedValueChecker
+			converter
+		}{st, nc, converter{cc}}
+	case nc != nil:
+		return struct {
+			*stmt
+			driver.NamedValueChecker
+		}{st, nc}
+	case cc != nil:
+		return struct {
+			*stmt
+			converter
+		}{st, converter{cc}}
+	}
+	return st
+}
+
+// converter forwards driver.ColumnConverter, whose method can't be promoted
+// from an embedded field of the same name.
+type converter struct {
+	cc driver.ColumnConverter //nolint:staticcheck
+}
+
+func (c converter) ColumnConverter(idx int) driver.ValueConverter {
+	return c.cc.ColumnConverter(idx)
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.Stmt.Exec(args) //nolint:staticcheck
+	record(s.i.execTime, s.i.execErrors, start, err)
+	return res, err
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rs, err := s.Stmt.Query(args) //nolint:staticcheck
+	record(s.i.queryTime, s.i.queryErrors, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{Rows: rs, i: s.i}, nil
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	sc, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	start := time.Now()
+	res, err := sc.ExecContext(ctx, args)
+	record(s.i.execTime, s.i.execErrors, start, err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	sc, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	start := time.Now()
+	rs, err := sc.QueryContext(ctx, args)
+	record(s.i.queryTime, s.i.queryErrors, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{Rows: rs, i: s.i}, nil
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sqlinstr: driver does not support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+type tx struct {
+	driver.Tx
+	i     *driverInstruments
+	start time.Time
+}
+
+func (t *tx) Commit() error {
+	err := t.Tx.Commit()
+	record(t.i.txTime, t.i.txErrors, t.start, err)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	err := t.Tx.Rollback()
+	record(t.i.txTime, t.i.txErrors, t.start, err)
+	return err
+}
+
+type rows struct {
+	driver.Rows
+	i *driverInstruments
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.i.rows.Update(1)
+	}
+	return err
+}
+
+func (r *rows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+	return io.EOF
+}
+
+// The column types methods return the database/sql defaults
+// when the driver rows don't implement them.
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return ct.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return ct.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return ct.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (bool, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return ct.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ct.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,381 @@
+package sqlinstr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// fakeDriver is an in-process driver returning as many rows as the query has words,
+// and failing on queries starting with "FAIL".
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+// custom is a type only the fake connection knows how to convert.
+type custom struct {
+	v int64
+}
+
+func (c *fakeConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := nv.Value.(custom); ok {
+		nv.Value = c.v
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, errors.New("fake: failed")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, errors.New("fake: failed")
+	}
+	return &fakeRows{n: len(strings.Fields(s.query))}, nil
+}
+
+type fakeRows struct {
+	n int
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"n"}
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return "BIGINT"
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.n == 0 {
+		return io.EOF
+	}
+	dest[0] = int64(r.n)
+	r.n--
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return errors.New("fake: rollback failed")
+}
+
+func init() {
+	sql.Register("sqlinstr-fake", Wrap(fakeDriver{}, reporter.DefaultRegistry.Sub("fake")))
+}
+
+func snapshot(name string) int64 {
+	switch i := reporter.DefaultRegistry.Sub("fake").Get(name).(type) {
+	case *instruments.Counter:
+		return i.Snapshot()
+	case *instruments.Timer:
+		return int64(len(i.Snapshot()))
+	}
+	return -1
+}
+
+func TestDriver(t *testing.T) {
+	db, err := sql.Open("sqlinstr-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT a b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if _, err := db.Query("FAIL"); err == nil {
+		t.Fatal("expected query to fail")
+	}
+	if _, err := db.Exec("INSERT", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	tx, _ = db.Begin()
+	if err := tx.Rollback(); err == nil {
+		t.Fatal("expected rollback to fail")
+	}
+
+	var tests = []struct {
+		name  string
+		value int64
+	}{
+		{"query.time", 2},
+		{"query.errors", 1},
+		{"rows", 3},
+		{"exec.time", 1},
+		{"exec.errors", 0},
+		{"tx.time", 2},
+		{"tx.errors", 1},
+	}
+	for _, tt := range tests {
+		if v := snapshot(tt.name); v != tt.value {
+			t.Errorf("%s: wants %d got %d", tt.name, tt.value, v)
+		}
+	}
+}
+
+func TestDriverConnChecker(t *testing.T) {
+	db, err := sql.Open("sqlinstr-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s, err := db.Prepare("INSERT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Exec(custom{1}); err != nil {
+		t.Fatalf("expected the connection to convert the argument, got %v", err)
+	}
+}
+
+func TestDriverColumnTypes(t *testing.T) {
+	db, err := sql.Open("sqlinstr-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) != 1 || types[0].DatabaseTypeName() != "BIGINT" {
+		t.Errorf("expected the driver column type, got %v", types)
+	}
+	if _, ok := types[0].Nullable(); ok {
+		t.Error("nullable should not be supported by the driver")
+	}
+}
+
+// legacyDriver opens connections only implementing the legacy driver.Execer and driver.Queryer,
+// along with driver.Pinger.
+type legacyDriver struct {
+	execs *int
+}
+
+func (d legacyDriver) Open(name string) (driver.Conn, error) {
+	return &legacyConn{execs: d.execs}, nil
+}
+
+type legacyConn struct {
+	fakeConn
+	execs *int
+}
+
+func (c *legacyConn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	*c.execs++
+	return driver.RowsAffected(1), nil
+}
+
+func (c *legacyConn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{n: 2}, nil
+}
+
+func (c *legacyConn) Ping(ctx context.Context) error {
+	return errors.New("fake: ping failed")
+}
+
+func TestDriverLegacyConn(t *testing.T) {
+	r := reporter.NewRegistry()
+	var execs int
+	c, err := Wrap(legacyDriver{&execs}, r).OpenConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(c)
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT", 1); err != nil || execs != 1 {
+		t.Fatalf("expected the connection to execute the statement, got %d executions: %v", execs, err)
+	}
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if err := db.Ping(); err == nil {
+		t.Error("expected the connection ping to fail")
+	}
+	if s := r.Get("exec.time").(*instruments.Timer).Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 timed execution, got %d", len(s))
+	}
+	if s := r.Get("query.time").(*instruments.Timer).Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 timed query, got %d", len(s))
+	}
+	if c := r.Get("rows").(*instruments.Counter).Snapshot(); c != 2 {
+		t.Errorf("expected 2 rows, got %d", c)
+	}
+}
+
+func TestDriverOptionalInterfaces(t *testing.T) {
+	i := newDriverInstruments(reporter.NewRegistry())
+	c := wrapConn(&fakeConn{}, i)
+	if _, ok := c.(driver.Pinger); ok {
+		t.Error("connection should not implement driver.Pinger")
+	}
+	if _, ok := c.(driver.SessionResetter); ok {
+		t.Error("connection should not implement driver.SessionResetter")
+	}
+	if _, ok := c.(driver.Validator); ok {
+		t.Error("connection should not implement driver.Validator")
+	}
+	if _, ok := c.(driver.NamedValueChecker); !ok {
+		t.Error("connection should implement driver.NamedValueChecker")
+	}
+	if _, ok := wrapConn(&legacyConn{}, i).(driver.Pinger); !ok {
+		t.Error("connection should implement driver.Pinger")
+	}
+
+	s := wrapStmt(&fakeStmt{}, i)
+	if _, ok := s.(driver.NamedValueChecker); ok {
+		t.Error("statement should not implement driver.NamedValueChecker")
+	}
+	if _, ok := s.(driver.ColumnConverter); ok { //nolint:staticcheck
+		t.Error("statement should not implement driver.ColumnConverter")
+	}
+	if _, ok := wrapStmt(&convertingStmt{}, i).(driver.ColumnConverter); !ok { //nolint:staticcheck
+		t.Error("statement should implement driver.ColumnConverter")
+	}
+}
+
+type convertingStmt struct {
+	fakeStmt
+}
+
+func (s *convertingStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return driver.DefaultParameterConverter
+}
+
+// contextDriver opens connections through its own connector.
+type contextDriver struct {
+	fakeDriver
+	c *fakeConnector
+}
+
+func (d contextDriver) OpenConnector(name string) (driver.Connector, error) {
+	d.c.name, d.c.d = name, d
+	return d.c, nil
+}
+
+type fakeConnector struct {
+	name   string
+	d      driver.Driver
+	closed bool
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.name != "dsn" {
+		return nil, errors.New("fake: unexpected name")
+	}
+	return &fakeConn{}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return c.d
+}
+
+func (c *fakeConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDriverOpenConnector(t *testing.T) {
+	r := reporter.NewRegistry()
+	fc := &fakeConnector{}
+	c, err := Wrap(contextDriver{c: fc}, r).OpenConnector("dsn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(c)
+	if _, err := db.Exec("INSERT"); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Get("exec.time").(*instruments.Timer).Snapshot(); len(s) != 1 {
+		t.Errorf("expected 1 timed execution, got %d", len(s))
+	}
+	if _, ok := c.Driver().(*Driver); !ok {
+		t.Error("connector should return the wrapped driver")
+	}
+	db.Close()
+	if !fc.closed {
+		t.Error("connector should be closed with the database")
+	}
+}
+
+func ExampleWrap() {
+	sql.Register("instrumented", Wrap(fakeDriver{}, reporter.DefaultRegistry.Sub("db")))
+	db, _ := sql.Open("instrumented", "")
+	stats := NewStats(db, reporter.DefaultRegistry.Sub("db.pool"))
+	stats.Update()
+}
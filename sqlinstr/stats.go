@@ -0,0 +1,57 @@
+package sqlinstr
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+// Stats collects the connection pool statistics of a database into a registry:
+//
+// - open, inuse and idle: Gauges of open, in use and idle connections.
+//
+// - wait.count: Derive of connections waited for, per second.
+//
+// - wait.duration: Derive of the time spent waiting for connections, in milliseconds per second.
+type Stats struct {
+	db           *sql.DB
+	open         *instruments.Gauge
+	inUse        *instruments.Gauge
+	idle         *instruments.Gauge
+	waitCount    *instruments.Derive
+	waitDuration *instruments.Derive
+	m            sync.Mutex
+}
+
+// NewStats creates a new Stats registering its instruments into the given registry.
+func NewStats(db *sql.DB, r *reporter.Registry) *Stats {
+	newGauge := func() *instruments.Gauge {
+		return instruments.NewGauge(0)
+	}
+	newDerive := func() *instruments.Derive {
+		return instruments.NewDerive(0)
+	}
+	return &Stats{
+		db:           db,
+		open:         reporter.Instrument(r, "open", newGauge),
+		inUse:        reporter.Instrument(r, "inuse", newGauge),
+		idle:         reporter.Instrument(r, "idle", newGauge),
+		waitCount:    reporter.Instrument(r, "wait.count", newDerive),
+		waitDuration: reporter.Instrument(r, "wait.duration", newDerive),
+	}
+}
+
+// Update updates the connection pool statistics.
+func (s *Stats) Update() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	stats := s.db.Stats()
+	s.open.Update(int64(stats.OpenConnections))
+	s.inUse.Update(int64(stats.InUse))
+	s.idle.Update(int64(stats.Idle))
+	s.waitCount.Update(stats.WaitCount)
+	s.waitDuration.Update(stats.WaitDuration.Milliseconds())
+}
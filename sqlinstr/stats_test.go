@@ -0,0 +1,36 @@
+package sqlinstr
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/heroku/instruments"
+	"github.com/heroku/instruments/reporter"
+)
+
+func TestStats(t *testing.T) {
+	db, err := sql.Open("sqlinstr-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := reporter.NewRegistry()
+	s := NewStats(db, r)
+	s.Update()
+	if v := r.Get("open").(*instruments.Gauge).Snapshot(); v != 1 {
+		t.Errorf("expected 1 open connection, got %d", v)
+	}
+	if v := r.Get("inuse").(*instruments.Gauge).Snapshot(); v != 1 {
+		t.Errorf("expected 1 connection in use, got %d", v)
+	}
+	if v := r.Get("idle").(*instruments.Gauge).Snapshot(); v != 0 {
+		t.Errorf("expected no idle connection, got %d", v)
+	}
+}